@@ -0,0 +1,74 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jedrecord/kutil/pkg/resources"
+)
+
+// Handle "kutil diff <before> <after>", comparing the allocation of two snapshots
+func diff(args []string, f resources.Filter, v views) error {
+	if len(args) != 2 {
+		return errors.New("usage: kutil diff <before> <after>")
+	}
+	if err := v.checkOutput("diff"); err != nil {
+		return err
+	}
+	before, err := loadFile(args[0], f)
+	if err != nil {
+		return err
+	}
+	after, err := loadFile(args[1], f)
+	if err != nil {
+		return err
+	}
+	d := resources.Diff(before, after)
+	d.Display = v.display
+
+	// Every section is shown unless specific views were requested
+	all := !v.namespaces && !v.nodes && !v.cluster
+	switch {
+	case v.template != nil:
+		return v.template.Print(d)
+	case v.output == "json":
+		err = d.PrintJSON()
+	case v.output == "yaml":
+		err = d.PrintYAML()
+	default:
+		if all || v.nodes {
+			d.PrintNodeDiff()
+			fmt.Println()
+		}
+		if all || v.namespaces {
+			d.PrintNamespaceDiff()
+			fmt.Println()
+		}
+		if all || v.cluster {
+			d.PrintClusterDiff()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("could not serialize diff: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jedrecord/kutil/pkg/client"
+	"github.com/jedrecord/kutil/pkg/resources"
+)
+
+// How long to wait on each cluster before reporting it unreachable
+const fleetTimeout = 30 * time.Second
+
+// Load every context concurrently and print the fleet summary
+// A cluster that fails to load becomes an error row in the summary.
+func fleet(o client.Options, contexts []string, f resources.Filter, v views) error {
+	if err := v.checkOutput("fleet summary"); err != nil {
+		return err
+	}
+	myfleet := resources.NewFleet()
+	myfleet.Warn, myfleet.Crit, myfleet.Display = v.warn, v.crit, v.display
+	var wg sync.WaitGroup
+	for _, name := range contexts {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			c, err := loadContext(o, name, f)
+			myfleet.Add(name, c, err)
+		}(name)
+	}
+	wg.Wait()
+
+	switch {
+	case v.template != nil:
+		return v.template.Print(myfleet.Report())
+	case v.output == "json":
+		return myfleet.Report().PrintJSON()
+	case v.output == "yaml":
+		return myfleet.Report().PrintYAML()
+	}
+	myfleet.PrintFleetSummary()
+	return nil
+}
+
+// Build a client for a single kubeconfig context and load its Clustermetrics
+func loadContext(o client.Options, context string, f resources.Filter) (*resources.Clustermetrics, error) {
+	o.Context = context
+	config, err := client.Config(o)
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = fleetTimeout
+	clientset, err := newClientset(config)
+	if err != nil {
+		return nil, err
+	}
+	c := resources.NewCluster()
+	c.Filter = f
+	if err := c.Load(clientset); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
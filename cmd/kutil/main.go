@@ -20,15 +20,19 @@ Full license text at: https://gnu.org/licenses/gpl-2.0.txt
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 
+	"github.com/jedrecord/kutil/pkg/client"
 	"github.com/jedrecord/kutil/pkg/resources"
 	"github.com/jedrecord/kutil/pkg/utils"
 	"github.com/pborman/getopt/v2"
+	"golang.org/x/term"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/rest"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 func showVersion() {
@@ -47,20 +51,57 @@ func main() {
 	 *  Command line options
 	 */
 	// These options require a value
-	//	nameFlag := getopt.StringLong("namespace", 'n', "", "namespace to query")
-	//	nodeFlag := getopt.StringLong("node", rune(0), "", "node name or label to query")
-	kubeconfig := getopt.StringLong("kubeconfig", rune(0), filepath.Join(os.Getenv("HOME"), "/.kube/config"), "path to kubeconfig file")
+	namespaceFlag := getopt.ListLong("namespace", 'n', "namespace name or glob to query (repeatable)", "namespace")
+	nodeFlag := getopt.ListLong("node", rune(0), "node name to query (repeatable)", "node")
+	selectorFlag := getopt.StringLong("selector", 'l', "", "node label selector to query", "selector")
+	podSelectorFlag := getopt.StringLong("pod-selector", rune(0), "", "pod label selector to query", "selector")
+	kubeconfig := getopt.StringLong("kubeconfig", rune(0), "", "path to kubeconfig file (default $KUBECONFIG or ~/.kube/config)")
+	contextFlag := getopt.StringLong("context", rune(0), "", "kubeconfig context to use", "context")
+	clusterNameFlag := getopt.StringLong("cluster-name", rune(0), "", "kubeconfig cluster to use", "cluster")
+	userFlag := getopt.StringLong("user", rune(0), "", "kubeconfig user to use", "user")
+	asFlag := getopt.StringLong("as", rune(0), "", "user to impersonate", "user")
+	asGroupFlag := getopt.ListLong("as-group", rune(0), "group to impersonate (repeatable)", "group")
+	contextsFlag := getopt.ListLong("contexts", rune(0), "show a fleet summary of these kubeconfig contexts", "a,b,c")
+	resourcesFlag := getopt.ListLong("resources", rune(0), "resources to show (default cpu,memory), ie: cpu,memory,nvidia.com/gpu", "a,b,c")
+	outputFlag := getopt.StringLong("output", 'o', "", "output format: json, yaml, wide, csv, tsv, custom-columns=, go-template=, go-template-file= or jsonpath=")
+	warnFlag := getopt.ListLong("warn", rune(0), "warn at these utilization percentages, ie: cpu=80,mem=85,pods=90", "res=pct")
+	critFlag := getopt.ListLong("crit", rune(0), "critical at these utilization percentages, ie: cpu=90,mem=95", "res=pct")
+	colorFlag := getopt.StringLong("color", rune(0), "auto", "color utilization on a terminal: auto, always or never", "when")
+	sortByFlag := getopt.StringLong("sort-by", rune(0), "", "sort tables by cpu, mem, pods, cpu-limit, mem-limit, name or role", "key")
+	labelKeyFlag := getopt.StringLong("label-key", rune(0), "", "show this node label in the LABEL column instead of the node roles", "key")
+	groupByFlag := getopt.StringLong("group-by-label", rune(0), "", "roll the node summary up by this node label, ie: eks.amazonaws.com/nodegroup", "key")
+	topFlag := getopt.IntLong("top", rune(0), 0, "show only the first N rows of each table", "N")
+	watchFlag := getopt.StringLong("watch", 'w', "", "keep refreshing every interval (default 2s)", "interval")
+	fromFileFlag := getopt.StringLong("from-file", 'f', "", "read nodes and pods from a snapshot or kubectl JSON/YAML file instead of a cluster", "file")
+	listenFlag := getopt.StringLong("listen", rune(0), defaultListen, "address for the serve command to listen on", "address")
+	getopt.Lookup("watch").SetOptional()
 
 	// Boolean options
 	namespacesFlag := getopt.BoolLong("namespaces", rune(0), "show namespaces summary")
 	nodesFlag := getopt.BoolLong("nodes", rune(0), "show nodes summary")
 	clusterFlag := getopt.BoolLong("cluster", rune(0), "show cluster utilization")
+	pendingFlag := getopt.BoolLong("pending", rune(0), "show pods waiting to be scheduled")
+	overcommitFlag := getopt.BoolLong("overcommit", rune(0), "show nodes ranked by memory limit overcommit")
+	barsFlag := getopt.BoolLong("bars", rune(0), "draw a bar next to each utilization")
+	reverseFlag := getopt.BoolLong("reverse", rune(0), "reverse the sort order")
+	containersFlag := getopt.BoolLong("containers", rune(0), "show each container with the pods command")
+	noMetricsFlag := getopt.BoolLong("no-metrics", rune(0), "skip actual usage from the metrics API")
+	readyOnlyFlag := getopt.BoolLong("ready-only", rune(0), "count only pods with a ready container")
+	allContextsFlag := getopt.BoolLong("all-contexts", rune(0), "show a fleet summary of every kubeconfig context")
 	versionFlag := getopt.BoolLong("version", 'v', "show program version info")
 	helpFlag := getopt.BoolLong("help", 'h', "show help summary")
 
 	// Parse command line options
+	getopt.SetParameters("[pods | ui | serve | snapshot save <file> | diff <before> <after>]")
 	getopt.Parse()
 
+	// A command may be given, followed by more options
+	var command string
+	if getopt.NArgs() > 0 {
+		command = getopt.Arg(0)
+		getopt.CommandLine.Parse(getopt.Args())
+	}
+
 	// Just show version and exit if versionFlag provided
 	if *versionFlag {
 		showVersion()
@@ -72,48 +113,488 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Bail out if we don't have a proper kubeconfig
-	if !utils.FileExists(*kubeconfig) {
-		utils.LogError("Could not access kubeconfig file")
+	// A check with thresholds reports errors as UNKNOWN rather than WARNING
+	logError := utils.LogError
+	if len(*warnFlag) > 0 || len(*critFlag) > 0 {
+		logError = checkError
 	}
 
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	// Bail out early on an unknown command or output format, before talking to the cluster
+	switch command {
+	case "", "pods", "ui", "serve", "snapshot", "diff":
+	default:
+		logError(fmt.Sprintf("Unknown command %q", command))
+	}
+	columns, tmpl, err := outputFormat(*outputFlag)
 	if err != nil {
-		utils.LogError("Could not parse kubeconfig file")
+		logError(err.Error())
+	}
+	order := resources.Order{By: *sortByFlag, Reverse: *reverseFlag, Top: *topFlag}
+	if err := order.Validate(); err != nil {
+		logError(err.Error())
+	}
+	warn, crit, err := thresholds(*warnFlag, *critFlag)
+	if err != nil {
+		logError(err.Error())
+	}
+	resnames := resourceNames(*resourcesFlag)
+	if err := resources.ValidateResources(resnames); err != nil {
+		logError(err.Error())
+	}
+	disp, err := display(*colorFlag, *barsFlag)
+	if err != nil {
+		logError(err.Error())
+	}
+
+	// Bail out if we don't have a proper kubeconfig or in-cluster service account
+	if len(*kubeconfig) > 0 && !utils.FileExists(*kubeconfig) {
+		logError("Could not access kubeconfig file")
+	}
+	copts := client.Options{
+		Kubeconfig: *kubeconfig,
+		Context:    *contextFlag,
+		Cluster:    *clusterNameFlag,
+		User:       *userFlag,
+		As:         *asFlag,
+		AsGroups:   *asGroupFlag,
+	}
+
+	// Limit the nodes and pods we collect, pushed down into the API list calls
+	filter := resources.Filter{
+		Namespaces:   *namespaceFlag,
+		Nodes:        *nodeFlag,
+		NodeSelector: *selectorFlag,
+		PodSelector:  *podSelectorFlag,
+		ReadyOnly:    *readyOnlyFlag,
+	}
+
+	// The views to print and the format to print them in
+	v := views{
+		output:     *outputFlag,
+		nodes:      *nodesFlag,
+		namespaces: *namespacesFlag,
+		cluster:    *clusterFlag,
+		pending:    *pendingFlag,
+		overcommit: *overcommitFlag,
+		pods:       command == "pods",
+		containers: *containersFlag,
+		resources:  resnames,
+		order:      order,
+		columns:    columns,
+		template:   tmpl,
+		warn:       warn,
+		crit:       crit,
+		display:    disp,
+		labelKey:   *labelKeyFlag,
+		groupBy:    *groupByFlag,
+	}
+
+	// Compare two saved snapshots, no cluster access needed
+	if command == "diff" {
+		if err := diff(getopt.Args(), filter, v); err != nil {
+			logError(err.Error())
+		}
+		os.Exit(0)
+	}
+
+	// Analyze a saved snapshot instead of a live cluster
+	if len(*fromFileFlag) > 0 {
+		mycluster, err := loadFile(*fromFileFlag, filter)
+		if err != nil {
+			logError(err.Error())
+		}
+		if command == "ui" {
+			if err := runDashboard(source{load: func() (*resources.Clustermetrics, error) { return mycluster, nil }}, v); err != nil {
+				logError(err.Error())
+			}
+			os.Exit(0)
+		}
+		status := v.check(mycluster)
+		if err := v.print(mycluster); err != nil {
+			logError(err.Error())
+		}
+		os.Exit(status)
+	}
+
+	// Summarize several clusters at once, one per kubeconfig context
+	if *allContextsFlag || len(*contextsFlag) > 0 {
+		contexts := *contextsFlag
+		if *allContextsFlag {
+			var err error
+			if contexts, err = client.Contexts(copts); err != nil {
+				logError("Could not load kubeconfig: " + err.Error())
+			}
+		}
+		if err := fleet(copts, contexts, filter, v); err != nil {
+			logError(err.Error())
+		}
+		os.Exit(0)
+	}
+
+	config, err := client.Config(copts)
+	if err != nil {
+		logError("Could not load kubeconfig: " + err.Error())
 	}
-	config.AcceptContentTypes = "application/vnd.kubernetes.protobuf, application/json"
-	config.ContentType = "application/vnd.kubernetes.protobuf"
 
 	// Build a valid set of credentials for a kubernetes cluster, returns pointer or err
-	clientset, err := kubernetes.NewForConfig(config)
+	clientset, err := newClientset(config)
 	if err != nil {
-		utils.LogError("There was a problem parsing kubeconfig")
+		logError("There was a problem parsing kubeconfig")
+	}
+
+	// Actual usage comes from metrics-server when it is available
+	var mc metrics.Interface
+	if !*noMetricsFlag {
+		mc, err = newMetricsClient(config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Note: showing requests only, actual usage unavailable: %v\n", err)
+		}
+	}
+
+	// Save the raw nodes and pods for offline analysis
+	if command == "snapshot" {
+		if err := snapshot(clientset, filter, getopt.Args()); err != nil {
+			logError(err.Error())
+		}
+		os.Exit(0)
+	}
+
+	// Serve Prometheus metrics from informer caches until stopped
+	if command == "serve" {
+		if err := serve(clientset, mc, filter, *listenFlag); err != nil {
+			logError(err.Error())
+		}
+		os.Exit(0)
+	}
+
+	// Browse the cluster in a full-screen dashboard kept current from informer caches
+	if command == "ui" {
+		if err := dashboardLive(clientset, mc, filter, v); err != nil {
+			logError(err.Error())
+		}
+		os.Exit(0)
+	}
+
+	// Keep redrawing from informer caches until interrupted
+	if getopt.IsSet("watch") {
+		interval, err := parseInterval(*watchFlag)
+		if err != nil {
+			logError(err.Error())
+		}
+		if err := watch(clientset, mc, filter, interval, v); err != nil {
+			logError(err.Error())
+		}
+		os.Exit(0)
 	}
 
 	// Create a Clustermetrics object (struct) to hold the current k8s resources data state
 	// (Clustermetrics{} defined in pkg/resources/resources.go)
 	mycluster := resources.NewCluster()
+	mycluster.Filter = filter
 
 	// Connect with the cluster and collect current state
-	// Requires a pointer to a valid clientset
+	// Requires a valid clientset (any kubernetes.Interface)
 	// See Clustermetrics{} functions in pkg/resources/resources.go
-	mycluster.Load(clientset)
+	if err := mycluster.Load(clientset); err != nil {
+		logError(err.Error())
+	}
+
+	// Add actual usage when available, otherwise carry on with requests and limits only
+	if mc != nil {
+		if err := mycluster.LoadUsage(mc); err != nil {
+			fmt.Fprintf(os.Stderr, "Note: showing requests only, actual usage unavailable (is metrics-server installed?): %v\n", err)
+		}
+	}
+
+	status := v.check(mycluster)
+	if err := v.print(mycluster); err != nil {
+		logError(err.Error())
+	}
+	os.Exit(status)
+}
+
+// Parse the --output format, returning the custom columns or template it holds
+func outputFormat(output string) ([]resources.Column, *resources.Template, error) {
+	kind, arg := output, ""
+	if i := strings.Index(output, "="); i >= 0 {
+		kind, arg = output[:i], output[i+1:]
+	}
+	switch kind {
+	case "", "json", "yaml", "wide", "csv", "tsv":
+		if len(arg) == 0 {
+			return nil, nil, nil
+		}
+	case "custom-columns":
+		cols, err := resources.ParseColumns(arg)
+		return cols, nil, err
+	case "go-template":
+		t, err := resources.NewGoTemplate(arg)
+		return nil, t, err
+	case "go-template-file":
+		b, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read template: %w", err)
+		}
+		t, err := resources.NewGoTemplate(string(b))
+		return nil, t, err
+	case "jsonpath":
+		t, err := resources.NewJSONPath(arg)
+		return nil, t, err
+	}
+	return nil, nil, fmt.Errorf("unknown output format %q", output)
+}
+
+// Return an error for an output format a view can not print, ie: csv for the fleet
+// summary, rather than falling back to its table
+func (v views) checkOutput(view string) error {
+	if v.template != nil {
+		return nil
+	}
+	switch v.output {
+	case "", "json", "yaml":
+		return nil
+	}
+	kind := strings.SplitN(v.output, "=", 2)[0]
+	return fmt.Errorf("-o %s is not available for the %s, use json, yaml, go-template or jsonpath", kind, view)
+}
+
+// Detect how tables are displayed on stdout
+// Colors and fitting the tables to the width only apply to a terminal, and
+// colors are off when NO_COLOR is set (https://no-color.org).
+func display(color string, bars bool) (resources.Display, error) {
+	fd := int(os.Stdout.Fd())
+	tty := term.IsTerminal(fd)
+	d := resources.Display{Bars: bars}
+	switch color {
+	case "auto":
+		d.Color = tty && len(os.Getenv("NO_COLOR")) == 0
+	case "always":
+		d.Color = true
+	case "never":
+	default:
+		return d, fmt.Errorf("unknown color mode %q, use auto, always or never", color)
+	}
+	if tty {
+		if w, _, err := term.GetSize(fd); err == nil {
+			d.Width = w
+		}
+	}
+	return d, nil
+}
+
+// Parse the --warn and --crit thresholds, accepting the short names of --resources
+func thresholds(warnSpecs []string, critSpecs []string) (resources.Thresholds, resources.Thresholds, error) {
+	var parsed []resources.Thresholds
+	for _, specs := range [][]string{warnSpecs, critSpecs} {
+		var s []string
+		for _, spec := range specs {
+			kv := strings.SplitN(spec, "=", 2)
+			kv[0] = resourceNames(kv[:1])[0]
+			s = append(s, strings.Join(kv, "="))
+		}
+		t, err := resources.ParseThresholds(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		parsed = append(parsed, t)
+	}
+	warn, crit := parsed[0], parsed[1]
+	for res, pct := range crit {
+		if w, ok := warn[res]; ok && w > pct {
+			return nil, nil, fmt.Errorf("critical threshold %s=%d is below the warning threshold %d", res, pct, w)
+		}
+	}
+	return warn, crit, nil
+}
+
+// Print an error as the summary line of a check and exit UNKNOWN
+func checkError(msg string) {
+	fmt.Printf("KUTIL UNKNOWN - %s\n", msg)
+	os.Exit(resources.AlertUnknown)
+}
+
+// Expand the short names accepted by --resources, ie: mem for memory
+func resourceNames(names []string) []string {
+	var s []string
+	for _, n := range names {
+		switch n {
+		case "mem":
+			n = "memory"
+		case "storage":
+			n = "ephemeral-storage"
+		case "gpu":
+			n = "nvidia.com/gpu"
+		}
+		s = append(s, n)
+	}
+	return s
+}
+
+// The summaries selected on the command line and the format to print them in
+type views struct {
+	output     string
+	nodes      bool
+	namespaces bool
+	cluster    bool
+	pending    bool
+	overcommit bool
+	pods       bool
+	containers bool
+	resources  []string
+	order      resources.Order
+	columns    []resources.Column
+	template   *resources.Template
+	warn       resources.Thresholds
+	crit       resources.Thresholds
+	display    resources.Display
+	labelKey   string
+	groupBy    string
+}
+
+// Report whether specific summaries were requested on the command line
+func (v views) selected() bool {
+	return v.namespaces || v.nodes || v.cluster || v.pending || v.overcommit
+}
+
+// Report whether the output is a structured report rather than tables
+func (v views) structured() bool {
+	return v.output == "json" || v.output == "yaml"
+}
+
+// Apply the display settings of the views to a cluster
+func (v views) configure(c *resources.Clustermetrics) {
+	c.Resources = v.resources
+	c.Order = v.order
+	c.Warn = v.warn
+	c.Crit = v.crit
+	c.Display = v.display
+	c.LabelKey = v.labelKey
+	c.GroupBy = v.groupBy
+}
+
+// Check the thresholds and print a one line summary, returning the exit code
+// The summary is the first line of the output, as a Nagios check reports it. With
+// structured output it goes to stderr to keep stdout parseable. Nodes and the
+// cluster are checked unless specific views were requested.
+func (v views) check(c *resources.Clustermetrics) int {
+	if len(v.warn) == 0 && len(v.crit) == 0 {
+		return resources.AlertOK
+	}
+	v.configure(c)
+	alerts := c.Alerts(v.nodes || v.overcommit || (!v.namespaces && !v.cluster), v.namespaces, v.cluster || !v.selected())
+	out := os.Stdout
+	if len(v.output) > 0 && v.output != "wide" {
+		out = os.Stderr
+	}
+	fmt.Fprintln(out, resources.AlertSummary(alerts))
+	return resources.AlertStatus(alerts)
+}
+
+// Print the selected summaries of a cluster
+func (v views) print(c *resources.Clustermetrics) error {
+	v.configure(c)
+
+	// Custom columns are read from the selected sections of the report, the nodes by default
+	if len(v.columns) > 0 {
+		report := c.Report(!v.selected() || v.nodes || v.overcommit, v.namespaces, v.cluster, v.pending)
+		if v.pods {
+			report = c.PodReport(v.containers)
+		}
+		return report.PrintColumns(v.columns, v.display)
+	}
+
+	// CSV has a table for each of the node, namespace and cluster views, the nodes by default
+	if v.output == "csv" || v.output == "tsv" {
+		if v.pods {
+			return errors.New("csv and tsv output are available for the node, namespace and cluster views")
+		}
+		comma := ','
+		if v.output == "tsv" {
+			comma = '\t'
+		}
+		return c.PrintCSV(comma, v.nodes || v.overcommit || (!v.namespaces && !v.cluster), v.namespaces, v.cluster)
+	}
+
+	// Structured output includes every section unless specific views were requested
+	if v.structured() || v.template != nil {
+		all := !v.selected()
+		report := c.Report(all || v.nodes || v.overcommit, all || v.namespaces, all || v.cluster, all || v.pending)
+		if v.pods {
+			report = c.PodReport(v.containers)
+		}
+		var err error
+		switch {
+		case v.template != nil:
+			return v.template.Print(report)
+		case v.output == "json":
+			err = report.PrintJSON()
+		default:
+			err = report.PrintYAML()
+		}
+		if err != nil {
+			return fmt.Errorf("could not serialize report: %w", err)
+		}
+		return nil
+	}
+
+	// The pods command lists pods instead of the summaries
+	if v.pods {
+		c.PrintPodSummary(v.containers)
+		return nil
+	}
+
+	// The wide output shows absolute values and more about each node
+	printNodes := c.PrintNodeSummary
+	if v.output == "wide" {
+		printNodes = c.PrintNodeWide
+	}
+	// Grouping by a label rolls the node summary up into one row per group
+	if len(v.groupBy) > 0 {
+		printNodes = c.PrintGroupSummary
+	}
 
 	// Determine output based on flag options (-namespaces, -nodes, -cluster)
-	if *namespacesFlag {
-		mycluster.PrintNamespaceSummary()
+	if v.namespaces {
+		c.PrintNamespaceSummary()
+	}
+	if v.nodes {
+		printNodes()
 	}
-	if *nodesFlag {
-		mycluster.PrintNodeSummary()
+	if v.overcommit {
+		c.PrintOvercommitSummary()
 	}
-	if *clusterFlag {
-		mycluster.PrintClusterSummary()
+	if v.pending {
+		c.PrintPendingSummary()
+	}
+	if v.cluster {
+		c.PrintClusterSummary()
 	}
 
 	// If no options selected default output is node and cluster summary
-	if !*namespacesFlag && !*nodesFlag && !*clusterFlag {
-		mycluster.PrintNodeSummary()
+	if !v.selected() {
+		printNodes()
 		fmt.Println()
-		mycluster.PrintClusterSummary()
+		c.PrintClusterSummary()
+	}
+	return nil
+}
+
+// Build a clientset from the cluster config, preferring protobuf over JSON
+func newClientset(config *rest.Config) (*kubernetes.Clientset, error) {
+	config.AcceptContentTypes = "application/vnd.kubernetes.protobuf, application/json"
+	config.ContentType = "application/vnd.kubernetes.protobuf"
+	return kubernetes.NewForConfig(config)
+}
+
+// Build a metrics.k8s.io client from the cluster config
+func newMetricsClient(config *rest.Config) (metrics.Interface, error) {
+	// The metrics API is served as JSON only
+	mconfig := rest.CopyConfig(config)
+	mconfig.AcceptContentTypes = "application/json"
+	mconfig.ContentType = "application/json"
+	mc, err := metrics.NewForConfig(mconfig)
+	if err != nil {
+		return nil, err
 	}
+	return mc, nil
 }
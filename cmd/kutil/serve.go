@@ -0,0 +1,62 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/jedrecord/kutil/pkg/exporter"
+	"github.com/jedrecord/kutil/pkg/resources"
+	"k8s.io/client-go/kubernetes"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// Default address for "kutil serve"
+const defaultListen = ":9737"
+
+// Serve Prometheus metrics on /metrics until the process is stopped
+// Like --watch, node and pod data come from informer caches so a scrape does
+// not cause any list calls against the API server.
+func serve(cs kubernetes.Interface, mc metrics.Interface, f resources.Filter, listen string) error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	w := resources.NewWatcher(cs, mc, f)
+	if err := w.Start(stop); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter.New(w))
+	mux.HandleFunc("/healthz", func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(rw, "ok")
+	})
+	mux.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(rw, r)
+			return
+		}
+		fmt.Fprintln(rw, `<html><head><title>kutil</title></head><body><h1>kutil</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+	})
+	fmt.Fprintf(os.Stderr, "Serving metrics on %s/metrics\n", listen)
+	return http.ListenAndServe(listen, mux)
+}
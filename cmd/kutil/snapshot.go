@@ -0,0 +1,89 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jedrecord/kutil/pkg/resources"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Handle "kutil snapshot save <file>", "-" writes the snapshot to stdout
+func snapshot(cs kubernetes.Interface, f resources.Filter, args []string) error {
+	if len(args) != 2 || args[0] != "save" {
+		return errors.New("usage: kutil snapshot save <file>")
+	}
+	s, err := resources.TakeSnapshot(cs, f)
+	if err != nil {
+		return err
+	}
+	if args[1] == "-" {
+		return s.Write(os.Stdout)
+	}
+	out, err := os.Create(args[1])
+	if err != nil {
+		return err
+	}
+	if err := s.Write(out); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Saved %d nodes and %d pods to %s\n", len(s.Nodes), len(s.Pods), args[1])
+	return nil
+}
+
+// Read a snapshot file, "-" reads from stdin
+func readSnapshot(path string) (*resources.Snapshot, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		in = file
+	}
+	s, err := resources.ReadSnapshot(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Build a Clustermetrics object from a snapshot file without contacting a cluster
+func loadFile(path string, f resources.Filter) (*resources.Clustermetrics, error) {
+	s, err := readSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	c := resources.NewCluster()
+	c.Filter = f
+	if err := c.LoadSnapshot(s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
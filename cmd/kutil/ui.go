@@ -0,0 +1,485 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jedrecord/kutil/pkg/resources"
+	"golang.org/x/term"
+	"k8s.io/client-go/kubernetes"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// Tabs of the dashboard
+const (
+	tabCluster = iota
+	tabNodes
+	tabNamespaces
+	tabPods
+)
+
+var tabNames = []string{"Cluster", "Nodes", "Namespaces", "Pods"}
+
+// Help shown on the last line of the dashboard
+const uiHelp = "↑↓ select  ←→ tab  enter pods  esc back  / filter  s sort  r reverse  c containers  q quit"
+
+// State of the dashboard between redraws
+type dashboard struct {
+	v          views
+	c          *resources.Clustermetrics
+	tab        int
+	selected   [4]int // Selected row of each tab
+	top        [4]int // First row shown on each tab
+	filter     string
+	typing     bool
+	node       string // Pods tab drilled down from a node
+	namespace  string // Pods tab drilled down from a namespace
+	from       int    // Tab the pods tab was drilled down from
+	containers bool
+	order      resources.Order
+	note       string
+}
+
+// Where the dashboard gets its cluster data from
+// Changes are picked up on the next tick so bursts of changes (ie: a rollout)
+// reload once. Usage changes without any object changing, so poll reloads on
+// every tick.
+type source struct {
+	load     func() (*resources.Clustermetrics, error)
+	changed  <-chan struct{}
+	tick     <-chan time.Time
+	poll     bool
+	usageErr func() error
+}
+
+// Run the dashboard on cluster data kept current from informer caches
+func dashboardLive(cs kubernetes.Interface, mc metrics.Interface, f resources.Filter, v views) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	w := resources.NewWatcher(cs, mc, f)
+	if err := w.Start(stop); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(defaultInterval)
+	defer ticker.Stop()
+	return runDashboard(source{
+		load: func() (*resources.Clustermetrics, error) {
+			c := resources.NewCluster()
+			return c, w.Load(c)
+		},
+		changed:  w.Changed(),
+		tick:     ticker.C,
+		poll:     mc != nil,
+		usageErr: w.UsageErr,
+	}, v)
+}
+
+// Run the dashboard until the user quits
+func runDashboard(src source, v views) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return errors.New("kutil ui needs a terminal")
+	}
+	d := &dashboard{v: v, order: v.order}
+	if err := d.reload(src); err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(in, state)
+	// Switch to the alternate screen and hide the cursor, restoring both on exit
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	dirty := false
+	for {
+		d.draw()
+		select {
+		case k, ok := <-keys:
+			if !ok || d.key(k) {
+				return nil
+			}
+		case <-src.changed:
+			dirty = true
+		case <-src.tick:
+			if dirty || src.poll {
+				if err := d.reload(src); err != nil {
+					d.note = err.Error()
+				}
+				dirty = false
+			}
+		case <-winch:
+		}
+	}
+}
+
+// Replace the cluster data, keeping the previous data on error
+func (d *dashboard) reload(src source) error {
+	c, err := src.load()
+	if err != nil {
+		return err
+	}
+	d.c, d.note = c, ""
+	if src.usageErr != nil {
+		if err := src.usageErr(); err != nil {
+			d.note = "showing requests only, actual usage unavailable"
+		}
+	}
+	return nil
+}
+
+// Read keys from the terminal until it is closed
+func readKeys(r io.Reader, keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// Names of the escape sequences of the keys the dashboard uses
+var keySequences = map[string]string{
+	"A": "up", "B": "down", "C": "right", "D": "left", "H": "home", "F": "end",
+	"Z": "shift-tab", "5~": "pgup", "6~": "pgdown", "1~": "home", "4~": "end",
+}
+
+// Translate terminal input into key names, ie: up, enter, q
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b && len(b) > 2 && (b[1] == '[' || b[1] == 'O'):
+			// A control sequence ends with a byte in the range @ to ~
+			i := 2
+			for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+				i++
+			}
+			if i == len(b) {
+				i--
+			}
+			if k, ok := keySequences[string(b[2:i+1])]; ok {
+				keys = append(keys, k)
+			}
+			b = b[i+1:]
+			continue
+		case b[0] == 0x1b:
+			keys = append(keys, "esc")
+		case b[0] == 0x03:
+			keys = append(keys, "ctrl-c")
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, "enter")
+		case b[0] == '\t':
+			keys = append(keys, "tab")
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, "backspace")
+		default:
+			r, n := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[n:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// Handle a key, returning true when the user quits
+func (d *dashboard) key(k string) bool {
+	if d.typing {
+		switch k {
+		case "ctrl-c":
+			return true
+		case "enter":
+			d.typing = false
+		case "esc":
+			d.typing, d.filter = false, ""
+		case "backspace":
+			if len(d.filter) > 0 {
+				_, n := utf8.DecodeLastRuneInString(d.filter)
+				d.filter = d.filter[:len(d.filter)-n]
+			}
+		default:
+			if utf8.RuneCountInString(k) == 1 {
+				d.filter += k
+			}
+		}
+		d.selected[d.tab], d.top[d.tab] = 0, 0
+		return false
+	}
+
+	switch k {
+	case "q", "ctrl-c":
+		return true
+	case "up", "k":
+		d.selected[d.tab]--
+	case "down", "j":
+		d.selected[d.tab]++
+	case "pgup":
+		d.selected[d.tab] -= 10
+	case "pgdown":
+		d.selected[d.tab] += 10
+	case "home", "g":
+		d.selected[d.tab] = 0
+	case "end", "G":
+		d.selected[d.tab] = 1 << 30
+	case "tab", "right", "l":
+		d.show((d.tab + 1) % len(tabNames))
+	case "shift-tab", "left", "h":
+		d.show((d.tab + len(tabNames) - 1) % len(tabNames))
+	case "1", "2", "3", "4":
+		d.show(int(k[0] - '1'))
+	case "/":
+		d.typing = true
+	case "s":
+		// Cycle through the sort keys, back to the default order after the last
+		next := ""
+		for i, key := range resources.SortKeys {
+			if key == d.order.By && i+1 < len(resources.SortKeys) {
+				next = resources.SortKeys[i+1]
+			}
+		}
+		if len(d.order.By) == 0 {
+			next = resources.SortKeys[0]
+		}
+		d.order.By = next
+	case "r":
+		d.order.Reverse = !d.order.Reverse
+	case "c":
+		d.containers = !d.containers
+	case "enter":
+		d.drill()
+	case "esc", "backspace":
+		d.back()
+	}
+	return false
+}
+
+// Switch to a tab, leaving any filter behind
+func (d *dashboard) show(tab int) {
+	d.tab, d.filter = tab, ""
+}
+
+// Show the pods of the selected node or namespace
+func (d *dashboard) drill() {
+	if d.tab != tabNodes && d.tab != tabNamespaces {
+		return
+	}
+	rows := d.view(0).Rows
+	if len(rows) == 0 {
+		return
+	}
+	key := rows[clamp(d.selected[d.tab], len(rows))].Key
+	d.node, d.namespace = "", ""
+	if d.tab == tabNodes {
+		d.node = key
+	} else {
+		d.namespace = key
+	}
+	d.from = d.tab
+	d.show(tabPods)
+	d.selected[tabPods], d.top[tabPods] = 0, 0
+}
+
+// Clear the filter, or return from the pods of a node or namespace
+func (d *dashboard) back() {
+	if len(d.filter) > 0 {
+		d.filter = ""
+		return
+	}
+	if d.tab == tabPods && (len(d.node) > 0 || len(d.namespace) > 0) {
+		d.node, d.namespace = "", ""
+		d.show(d.from)
+	}
+}
+
+// Return i limited to the rows of a view
+func clamp(i int, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// Build the view of the current tab, fitted to width when it is set
+func (d *dashboard) view(width int) *resources.View {
+	d.v.configure(d.c)
+	d.c.Order = d.order
+	d.c.Display.Width = width
+	var v *resources.View
+	switch d.tab {
+	case tabCluster:
+		v = d.c.ClusterView()
+	case tabNodes:
+		v = d.c.NodeView()
+	case tabNamespaces:
+		v = d.c.NamespaceView()
+	default:
+		v = d.c.PodView(d.node, d.namespace, d.containers)
+	}
+	return v.Filter(d.filter)
+}
+
+// Cut a line to w visible characters, keeping its color sequences intact
+func fit(s string, w int) string {
+	var b strings.Builder
+	n, colored := 0, false
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			end := strings.IndexByte(s[i:], 'm')
+			if end < 0 {
+				break
+			}
+			b.WriteString(s[i : i+end+1])
+			colored = true
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if n == w {
+			break
+		}
+		b.WriteRune(r)
+		n++
+		i += size
+	}
+	if colored {
+		b.WriteString("\033[0m")
+	}
+	return b.String()
+}
+
+// Draw the dashboard over the whole screen
+func (d *dashboard) draw() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	v := d.view(width)
+	var lines []string
+
+	// Tab bar with the current tab in reverse video
+	var tabs strings.Builder
+	tabs.WriteString(" kutil ")
+	for i, name := range tabNames {
+		label := fmt.Sprintf(" %d %s ", i+1, name)
+		if i == d.tab {
+			label = "\033[7m" + label + "\033[0m"
+		}
+		tabs.WriteString(" " + label)
+	}
+	lines = append(lines, tabs.String())
+
+	// What the current tab shows
+	var status []string
+	if d.tab == tabPods && len(d.node) > 0 {
+		status = append(status, "node "+d.node)
+	}
+	if d.tab == tabPods && len(d.namespace) > 0 {
+		status = append(status, "namespace "+d.namespace)
+	}
+	if len(d.order.By) > 0 {
+		status = append(status, "sort "+d.order.By)
+	}
+	if d.order.Reverse {
+		status = append(status, "reversed")
+	}
+	if d.typing || len(d.filter) > 0 {
+		f := "/" + d.filter
+		if d.typing {
+			f += "_"
+		}
+		status = append(status, f)
+	}
+	if len(d.note) > 0 {
+		status = append(status, d.note)
+	}
+	lines = append(lines, " "+strings.Join(status, "  ·  "), "")
+	lines = append(lines, v.Header...)
+
+	// Scroll the rows so the selected row stays on screen
+	body := height - len(lines) - 1
+	sel := clamp(d.selected[d.tab], len(v.Rows))
+	d.selected[d.tab] = sel
+	top := clamp(d.top[d.tab], len(v.Rows))
+	if sel < top {
+		top = sel
+	}
+	for len(v.Rows) > 0 {
+		n := 0
+		for _, row := range v.Rows[top : sel+1] {
+			n += len(row.Lines)
+		}
+		if n <= body || top >= sel {
+			break
+		}
+		top++
+	}
+	d.top[d.tab] = top
+	for i := top; i < len(v.Rows) && len(lines) < height-1; i++ {
+		row := v.Rows[i]
+		if i == sel && d.tab != tabCluster {
+			for _, l := range row.Plain() {
+				lines = append(lines, "\033[7m"+resources.Pad(fit(l, width), width)+"\033[0m")
+			}
+			continue
+		}
+		lines = append(lines, row.Lines...)
+	}
+	if len(lines) > height-1 {
+		lines = lines[:height-1]
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	out.WriteString("\033[H")
+	for _, l := range lines {
+		out.WriteString(fit(l, width) + "\033[K\r\n")
+	}
+	out.WriteString("\033[J")
+	fmt.Fprintf(out, "\033[%d;1H\033[2m%s\033[0m", height, fit(uiHelp, width))
+	out.Flush()
+}
@@ -0,0 +1,105 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jedrecord/kutil/pkg/resources"
+	"k8s.io/client-go/kubernetes"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// Default refresh interval for --watch, matching watch(1)
+const defaultInterval = 2 * time.Second
+
+// Parse the --watch value, either a duration (5s, 1m) or a number of seconds
+func parseInterval(s string) (time.Duration, error) {
+	if len(s) == 0 {
+		return defaultInterval, nil
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		s = fmt.Sprintf("%gs", secs)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid watch interval %q", s)
+	}
+	return d, nil
+}
+
+// Redraw the selected views whenever the cluster changes, checking at most once per interval
+// Node and pod data come from informer caches so only the initial sync lists
+// every object; the API server is not polled while watching.
+func watch(cs kubernetes.Interface, mc metrics.Interface, f resources.Filter, interval time.Duration, v views) error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	w := resources.NewWatcher(cs, mc, f)
+	if err := w.Start(stop); err != nil {
+		return err
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Usage changes without any object changing, so redraw on every tick when it is shown
+	dirty := true
+	for {
+		if dirty || mc != nil {
+			c := resources.NewCluster()
+			if err := w.Load(c); err != nil {
+				return err
+			}
+			// Structured output is streamed as one document per refresh instead of redrawn
+			if !v.structured() {
+				fmt.Print("\033[H\033[2J")
+				fmt.Printf("Every %v: kutil%*s\n\n", interval, 40, time.Now().Format("Mon Jan 2 15:04:05 2006"))
+			}
+			if err := v.print(c); err != nil {
+				return err
+			}
+			if err := w.UsageErr(); err != nil && !v.structured() {
+				fmt.Printf("\nNote: showing requests only, actual usage unavailable: %v\n", err)
+			}
+			dirty = false
+		}
+		select {
+		case <-sigs:
+			return nil
+		case <-w.Changed():
+			// Wait for the next tick so bursts of changes (ie: a rollout) redraw once
+			dirty = true
+			select {
+			case <-ticker.C:
+			case <-sigs:
+				return nil
+			}
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+// Package client Build Kubernetes client configuration from kubeconfig files or the in-cluster service account
+package client
+
+import (
+	"errors"
+	"sort"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Options Kubeconfig selection and impersonation settings
+// Empty fields fall back to the kubeconfig defaults, the same as kubectl.
+type Options struct {
+	Kubeconfig string   // Path to a kubeconfig file, overrides $KUBECONFIG
+	Context    string   // Kubeconfig context to use instead of the current context
+	Cluster    string   // Kubeconfig cluster to use
+	User       string   // Kubeconfig user to use
+	As         string   // User to impersonate
+	AsGroups   []string // Groups to impersonate
+}
+
+// Config Build a rest.Config for the cluster selected by the options
+// Kubeconfig files are loaded like kubectl does: the --kubeconfig path if
+// given, otherwise every file in $KUBECONFIG merged, otherwise ~/.kube/config.
+// When no kubeconfig is found and we are running inside a pod, the pod's
+// service account is used instead.
+func Config(o Options) (*rest.Config, error) {
+	config, err := clientConfig(o).ClientConfig()
+	if err != nil {
+		if clientcmd.IsEmptyConfig(err) {
+			return nil, errors.New("no kubeconfig found and not running inside a cluster")
+		}
+		return nil, err
+	}
+	// The in-cluster config does not take the impersonation overrides into account
+	if len(o.As) > 0 || len(o.AsGroups) > 0 {
+		config.Impersonate = rest.ImpersonationConfig{UserName: o.As, Groups: o.AsGroups}
+	}
+	return config, nil
+}
+
+// Build a deferred loading client config from the options
+func clientConfig(o Options) clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: o.Context,
+		Context: api.Context{
+			Cluster:  o.Cluster,
+			AuthInfo: o.User,
+		},
+		AuthInfo: api.AuthInfo{
+			Impersonate:       o.As,
+			ImpersonateGroups: o.AsGroups,
+		},
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+}
+
+// Contexts Return the names of every context in the kubeconfig, sorted
+func Contexts(o Options) ([]string, error) {
+	raw, err := clientConfig(o).RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	var s []string
+	for name := range raw.Contexts {
+		s = append(s, name)
+	}
+	sort.Strings(s)
+	return s, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const kubeconfigA = `apiVersion: v1
+kind: Config
+current-context: alpha
+clusters:
+- name: alpha
+  cluster:
+    server: https://alpha.example.com:6443
+contexts:
+- name: alpha
+  context:
+    cluster: alpha
+    user: alice
+users:
+- name: alice
+  user:
+    token: alice-token
+`
+
+const kubeconfigB = `apiVersion: v1
+kind: Config
+clusters:
+- name: beta
+  cluster:
+    server: https://beta.example.com:6443
+contexts:
+- name: beta
+  context:
+    cluster: beta
+    user: bob
+users:
+- name: bob
+  user:
+    token: bob-token
+`
+
+// Write the kubeconfig files to a temporary directory and point $KUBECONFIG at them
+func setupKubeconfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.yaml")
+	if err := os.WriteFile(a, []byte(kubeconfigA), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(kubeconfigB), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", a+string(os.PathListSeparator)+b)
+	return a
+}
+
+func TestConfigMergesKubeconfigEnv(t *testing.T) {
+	setupKubeconfig(t)
+
+	config, err := Config(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "https://alpha.example.com:6443" {
+		t.Errorf("host = %q, want the current context alpha", config.Host)
+	}
+
+	// The beta context only exists in the second file
+	config, err = Config(Options{Context: "beta"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "https://beta.example.com:6443" || config.BearerToken != "bob-token" {
+		t.Errorf("host = %q token = %q, want beta and bob-token", config.Host, config.BearerToken)
+	}
+}
+
+func TestConfigOverrides(t *testing.T) {
+	a := setupKubeconfig(t)
+
+	// An explicit path ignores $KUBECONFIG, so the beta context is not known
+	if _, err := Config(Options{Kubeconfig: a, Context: "beta"}); err == nil {
+		t.Error("expected an error for a context missing from the explicit kubeconfig")
+	}
+
+	config, err := Config(Options{Cluster: "beta", User: "bob", As: "jane", AsGroups: []string{"admins"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "https://beta.example.com:6443" || config.BearerToken != "bob-token" {
+		t.Errorf("host = %q token = %q, want beta and bob-token", config.Host, config.BearerToken)
+	}
+	if config.Impersonate.UserName != "jane" || len(config.Impersonate.Groups) != 1 || config.Impersonate.Groups[0] != "admins" {
+		t.Errorf("impersonate = %+v, want jane in admins", config.Impersonate)
+	}
+}
+
+func TestContexts(t *testing.T) {
+	setupKubeconfig(t)
+
+	contexts, err := Contexts(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contexts) != 2 || contexts[0] != "alpha" || contexts[1] != "beta" {
+		t.Errorf("contexts = %v, want [alpha beta]", contexts)
+	}
+}
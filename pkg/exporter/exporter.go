@@ -0,0 +1,275 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+// Package exporter Serve Kubernetes utilization resources as Prometheus metrics
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedrecord/kutil/pkg/resources"
+)
+
+// Loader Anything that can fill a Clustermetrics object, ie: a resources.Watcher
+type Loader interface {
+	Load(c *resources.Clustermetrics) error
+}
+
+// Exporter An http.Handler serving the metrics in the Prometheus text exposition format
+type Exporter struct {
+	loader Loader
+}
+
+// New constructor
+func New(l Loader) *Exporter {
+	return &Exporter{loader: l}
+}
+
+// ServeHTTP Collect a fresh Clustermetrics object and write it out for each scrape
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c := resources.NewCluster()
+	if err := e.loader.Load(c); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := Write(w, c); err != nil {
+		// The status line is already sent, all that is left is to log it
+		log.Printf("could not write metrics: %v", err)
+	}
+}
+
+// A gauge reported once per node, namespace or cluster
+type nodeGauge struct {
+	name  string
+	help  string
+	value func(n *resources.Nodemetrics) float64
+}
+
+type nsGauge struct {
+	name  string
+	help  string
+	value func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64
+}
+
+type clusterGauge struct {
+	name  string
+	help  string
+	value func(c *resources.Clustermetrics) float64
+}
+
+// Return the fraction of avail taken by inuse, or 0 when nothing is available
+func ratio(avail int64, inuse int64) float64 {
+	if avail <= 0 {
+		return 0
+	}
+	return float64(inuse) / float64(avail)
+}
+
+// Return 1 for true and 0 for false
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var nodeGauges = []nodeGauge{
+	{"kutil_node_cpu_requested_millicores", "CPU requested by pods on the node", func(n *resources.Nodemetrics) float64 { return float64(n.Cpu.Req) }},
+	{"kutil_node_cpu_limit_millicores", "CPU limits of pods on the node", func(n *resources.Nodemetrics) float64 { return float64(n.Cpu.Limit) }},
+	{"kutil_node_cpu_allocatable_millicores", "CPU allocatable on the node", func(n *resources.Nodemetrics) float64 { return float64(n.Cpu.Avail) }},
+	{"kutil_node_cpu_capacity_millicores", "CPU capacity of the node", func(n *resources.Nodemetrics) float64 { return float64(n.Cpu.Cap) }},
+	{"kutil_node_cpu_utilization_ratio", "Fraction of allocatable CPU requested", func(n *resources.Nodemetrics) float64 { return ratio(n.Cpu.Avail, n.Cpu.Req) }},
+	{"kutil_node_cpu_overcommit_ratio", "CPU limits as a fraction of allocatable CPU", func(n *resources.Nodemetrics) float64 { return ratio(n.Cpu.Avail, n.Cpu.Limit) }},
+	{"kutil_node_memory_requested_bytes", "Memory requested by pods on the node", func(n *resources.Nodemetrics) float64 { return float64(n.Mem.Req) }},
+	{"kutil_node_memory_limit_bytes", "Memory limits of pods on the node", func(n *resources.Nodemetrics) float64 { return float64(n.Mem.Limit) }},
+	{"kutil_node_memory_allocatable_bytes", "Memory allocatable on the node", func(n *resources.Nodemetrics) float64 { return float64(n.Mem.Avail) }},
+	{"kutil_node_memory_capacity_bytes", "Memory capacity of the node", func(n *resources.Nodemetrics) float64 { return float64(n.Mem.Cap) }},
+	{"kutil_node_memory_utilization_ratio", "Fraction of allocatable memory requested", func(n *resources.Nodemetrics) float64 { return ratio(n.Mem.Avail, n.Mem.Req) }},
+	{"kutil_node_memory_overcommit_ratio", "Memory limits as a fraction of allocatable memory", func(n *resources.Nodemetrics) float64 { return ratio(n.Mem.Avail, n.Mem.Limit) }},
+	{"kutil_node_pods", "Pods running on the node", func(n *resources.Nodemetrics) float64 { return float64(n.Pods.Inuse) }},
+	{"kutil_node_pods_allocatable", "Pods allocatable on the node", func(n *resources.Nodemetrics) float64 { return float64(n.Pods.Avail) }},
+	{"kutil_node_pods_capacity", "Pod capacity of the node", func(n *resources.Nodemetrics) float64 { return float64(n.Pods.Cap) }},
+	{"kutil_node_pods_utilization_ratio", "Fraction of allocatable pods in use", func(n *resources.Nodemetrics) float64 { return ratio(n.Pods.Avail, n.Pods.Inuse) }},
+	{"kutil_node_schedulable", "Whether new pods can be scheduled on the node", func(n *resources.Nodemetrics) float64 { return boolValue(n.Sched) }},
+}
+
+var nodeUsageGauges = []nodeGauge{
+	{"kutil_node_cpu_used_millicores", "CPU used on the node according to the metrics API", func(n *resources.Nodemetrics) float64 { return float64(n.Cpu.Used) }},
+	{"kutil_node_memory_used_bytes", "Memory used on the node according to the metrics API", func(n *resources.Nodemetrics) float64 { return float64(n.Mem.Used) }},
+}
+
+var nsGauges = []nsGauge{
+	{"kutil_namespace_cpu_requested_millicores", "CPU requested by pods in the namespace", func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64 { return float64(n.Cpu.Req) }},
+	{"kutil_namespace_cpu_limit_millicores", "CPU limits of pods in the namespace", func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64 { return float64(n.Cpu.Limit) }},
+	{"kutil_namespace_cpu_utilization_ratio", "Fraction of the cluster's available CPU requested by the namespace", func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64 {
+		return ratio(c.Cpu.Avail, n.Cpu.Req)
+	}},
+	{"kutil_namespace_memory_requested_bytes", "Memory requested by pods in the namespace", func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64 { return float64(n.Mem.Req) }},
+	{"kutil_namespace_memory_limit_bytes", "Memory limits of pods in the namespace", func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64 { return float64(n.Mem.Limit) }},
+	{"kutil_namespace_memory_utilization_ratio", "Fraction of the cluster's available memory requested by the namespace", func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64 {
+		return ratio(c.Mem.Avail, n.Mem.Req)
+	}},
+	{"kutil_namespace_pods", "Pods running in the namespace", func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64 { return float64(n.Pods.Inuse) }},
+}
+
+var nsUsageGauges = []nsGauge{
+	{"kutil_namespace_cpu_used_millicores", "CPU used by the namespace according to the metrics API", func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64 { return float64(n.Cpu.Used) }},
+	{"kutil_namespace_memory_used_bytes", "Memory used by the namespace according to the metrics API", func(c *resources.Clustermetrics, n *resources.Nsmetrics) float64 { return float64(n.Mem.Used) }},
+}
+
+var clusterGauges = []clusterGauge{
+	{"kutil_cluster_cpu_requested_millicores", "CPU requested by all pods", func(c *resources.Clustermetrics) float64 { return float64(c.Cpu.Req) }},
+	{"kutil_cluster_cpu_limit_millicores", "CPU limits of all pods", func(c *resources.Clustermetrics) float64 { return float64(c.Cpu.Limit) }},
+	{"kutil_cluster_cpu_available_millicores", "CPU available on schedulable nodes plus CPU already requested on unschedulable nodes", func(c *resources.Clustermetrics) float64 { return float64(c.Cpu.Avail) }},
+	{"kutil_cluster_cpu_capacity_millicores", "CPU capacity of all nodes", func(c *resources.Clustermetrics) float64 { return float64(c.Cpu.Cap) }},
+	{"kutil_cluster_cpu_utilization_ratio", "Fraction of available CPU requested", func(c *resources.Clustermetrics) float64 { return ratio(c.Cpu.Avail, c.Cpu.Req) }},
+	{"kutil_cluster_memory_requested_bytes", "Memory requested by all pods", func(c *resources.Clustermetrics) float64 { return float64(c.Mem.Req) }},
+	{"kutil_cluster_memory_limit_bytes", "Memory limits of all pods", func(c *resources.Clustermetrics) float64 { return float64(c.Mem.Limit) }},
+	{"kutil_cluster_memory_available_bytes", "Memory available on schedulable nodes plus memory already requested on unschedulable nodes", func(c *resources.Clustermetrics) float64 { return float64(c.Mem.Avail) }},
+	{"kutil_cluster_memory_capacity_bytes", "Memory capacity of all nodes", func(c *resources.Clustermetrics) float64 { return float64(c.Mem.Cap) }},
+	{"kutil_cluster_memory_utilization_ratio", "Fraction of available memory requested", func(c *resources.Clustermetrics) float64 { return ratio(c.Mem.Avail, c.Mem.Req) }},
+	{"kutil_cluster_pods", "Pods running in the cluster", func(c *resources.Clustermetrics) float64 { return float64(c.Pods.Inuse) }},
+	{"kutil_cluster_pods_available", "Pods available on schedulable nodes plus pods already running on unschedulable nodes", func(c *resources.Clustermetrics) float64 { return float64(c.Pods.Avail) }},
+	{"kutil_cluster_pods_capacity", "Pod capacity of all nodes", func(c *resources.Clustermetrics) float64 { return float64(c.Pods.Cap) }},
+	{"kutil_cluster_pods_utilization_ratio", "Fraction of available pods in use", func(c *resources.Clustermetrics) float64 { return ratio(c.Pods.Avail, c.Pods.Inuse) }},
+	{"kutil_cluster_pending_pods", "Pods waiting to be scheduled", func(c *resources.Clustermetrics) float64 { return float64(c.PendingTotals().Pods) }},
+	{"kutil_cluster_pending_cpu_requested_millicores", "CPU requested by pods waiting to be scheduled", func(c *resources.Clustermetrics) float64 { return float64(c.PendingTotals().Cpu) }},
+	{"kutil_cluster_pending_memory_requested_bytes", "Memory requested by pods waiting to be scheduled", func(c *resources.Clustermetrics) float64 { return float64(c.PendingTotals().Mem) }},
+}
+
+var clusterUsageGauges = []clusterGauge{
+	{"kutil_cluster_cpu_used_millicores", "CPU used by all nodes according to the metrics API", func(c *resources.Clustermetrics) float64 { return float64(c.Cpu.Used) }},
+	{"kutil_cluster_memory_used_bytes", "Memory used by all nodes according to the metrics API", func(c *resources.Clustermetrics) float64 { return float64(c.Mem.Used) }},
+}
+
+type extGauge struct {
+	name  string
+	help  string
+	value func(*resources.Restat) float64
+}
+
+var extGauges = []extGauge{
+	{"requested", "Extended resource requested by pods, in the resource's base unit", func(r *resources.Restat) float64 { return float64(r.Req) }},
+	{"limit", "Extended resource limits of pods, in the resource's base unit", func(r *resources.Restat) float64 { return float64(r.Limit) }},
+	{"allocatable", "Extended resource allocatable, in the resource's base unit", func(r *resources.Restat) float64 { return float64(r.Avail) }},
+	{"capacity", "Extended resource capacity, in the resource's base unit", func(r *resources.Restat) float64 { return float64(r.Cap) }},
+	{"utilization_ratio", "Fraction of the allocatable extended resource requested", func(r *resources.Restat) float64 { return ratio(r.Avail, r.Req) }},
+}
+
+// Write Write the metrics of a Clustermetrics object in the Prometheus text exposition format
+func Write(out io.Writer, c *resources.Clustermetrics) error {
+	w := bufio.NewWriter(out)
+
+	// Sort node and namespace names so scrapes are stable
+	var nodes []string
+	for n := range c.Nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	var namespaces []string
+	for n := range c.Namespaces {
+		namespaces = append(namespaces, n)
+	}
+	sort.Strings(namespaces)
+
+	ng := nodeGauges
+	nsg := nsGauges
+	cg := clusterGauges
+	if c.Usage {
+		ng = append(append([]nodeGauge{}, ng...), nodeUsageGauges...)
+		nsg = append(append([]nsGauge{}, nsg...), nsUsageGauges...)
+		cg = append(append([]clusterGauge{}, cg...), clusterUsageGauges...)
+	}
+
+	for _, g := range ng {
+		writeHeader(w, g.name, g.help)
+		for _, name := range nodes {
+			n := c.Nodes[name]
+			writeSample(w, g.name, g.value(n), "node", name, "role", n.Label)
+		}
+	}
+	for _, g := range nsg {
+		writeHeader(w, g.name, g.help)
+		for _, name := range namespaces {
+			writeSample(w, g.name, g.value(c, c.Namespaces[name]), "namespace", name)
+		}
+	}
+	for _, g := range cg {
+		writeHeader(w, g.name, g.help)
+		writeSample(w, g.name, g.value(c))
+	}
+
+	// Extended resources (ie: nvidia.com/gpu, ephemeral-storage) share gauges with a resource label
+	ext := c.ExtendedNames()
+	for _, g := range extGauges {
+		writeHeader(w, "kutil_node_resource_"+g.name, g.help)
+		for _, name := range nodes {
+			n := c.Nodes[name]
+			for _, res := range ext {
+				if r, ok := n.Extended[res]; ok {
+					writeSample(w, "kutil_node_resource_"+g.name, g.value(r), "node", name, "role", n.Label, "resource", res)
+				}
+			}
+		}
+		writeHeader(w, "kutil_cluster_resource_"+g.name, g.help)
+		for _, res := range ext {
+			writeSample(w, "kutil_cluster_resource_"+g.name, g.value(c.Extended[res]), "resource", res)
+		}
+	}
+	writeHeader(w, "kutil_metrics_api_available", "Whether actual usage was available from the metrics API")
+	writeSample(w, "kutil_metrics_api_available", boolValue(c.Usage))
+	return w.Flush()
+}
+
+// Write the HELP and TYPE lines of a gauge
+func writeHeader(w io.Writer, name string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+}
+
+// Write a single sample, labels are given as name and value pairs
+func writeSample(w io.Writer, name string, value float64, labels ...string) {
+	fmt.Fprint(w, name)
+	if len(labels) > 0 {
+		fmt.Fprint(w, "{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", labels[i], escape(labels[i+1]))
+		}
+		fmt.Fprint(w, "}")
+	}
+	fmt.Fprintf(w, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// Escape a label value for the text exposition format
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
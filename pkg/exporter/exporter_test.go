@@ -0,0 +1,118 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package exporter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jedrecord/kutil/pkg/resources"
+)
+
+// Build a small Clustermetrics object by hand
+func testCluster() *resources.Clustermetrics {
+	c := resources.NewCluster()
+	n := resources.NewNodemetrics()
+	n.Label = "worker"
+	n.Sched = true
+	n.Cpu = resources.Restat{Req: 1500, Limit: 3000, Avail: 4000, Cap: 4000, Util: 37}
+	n.Mem = resources.Restat{Req: 2 << 30, Avail: 8 << 30, Cap: 8 << 30, Util: 25}
+	n.Pods = resources.Imetric{Inuse: 3, Avail: 110, Cap: 110, Util: 2}
+	c.UpdateNode("node1", n)
+	ns := resources.NewNsmetrics()
+	ns.Cpu.Req = 1500
+	ns.Pods.Inuse = 3
+	c.UpdateNamespace(`odd"name`, ns)
+	c.Cpu = n.Cpu
+	c.Mem = n.Mem
+	c.Pods = n.Pods
+	return c
+}
+
+func TestWrite(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, testCluster()); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE kutil_node_cpu_requested_millicores gauge\n",
+		`kutil_node_cpu_requested_millicores{node="node1",role="worker"} 1500` + "\n",
+		`kutil_node_memory_allocatable_bytes{node="node1",role="worker"} 8.589934592e+09` + "\n",
+		`kutil_node_cpu_utilization_ratio{node="node1",role="worker"} 0.375` + "\n",
+		`kutil_node_schedulable{node="node1",role="worker"} 1` + "\n",
+		`kutil_namespace_cpu_requested_millicores{namespace="odd\"name"} 1500` + "\n",
+		`kutil_namespace_cpu_utilization_ratio{namespace="odd\"name"} 0.375` + "\n",
+		"kutil_cluster_pods_available 110\n",
+		"kutil_metrics_api_available 0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q", want)
+		}
+	}
+	if strings.Contains(out, "_used_") {
+		t.Error("usage metrics written without usage data")
+	}
+}
+
+func TestWriteExtended(t *testing.T) {
+	c := testCluster()
+	c.Nodes["node1"].Extended = map[string]*resources.Restat{"nvidia.com/gpu": {Req: 3, Avail: 4, Cap: 4}}
+	c.Extended = map[string]*resources.Restat{"nvidia.com/gpu": {Req: 3, Avail: 4, Cap: 4}}
+	var b bytes.Buffer
+	if err := Write(&b, c); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`kutil_node_resource_requested{node="node1",role="worker",resource="nvidia.com/gpu"} 3` + "\n",
+		`kutil_node_resource_utilization_ratio{node="node1",role="worker",resource="nvidia.com/gpu"} 0.75` + "\n",
+		`kutil_cluster_resource_allocatable{resource="nvidia.com/gpu"} 4` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q", want)
+		}
+	}
+}
+
+type staticLoader struct {
+	c *resources.Clustermetrics
+}
+
+func (l staticLoader) Load(c *resources.Clustermetrics) error {
+	*c = *l.c
+	return nil
+}
+
+func TestServeHTTP(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(staticLoader{testCluster()}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("content type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "kutil_cluster_cpu_requested_millicores 1500\n") {
+		t.Error("cluster metrics missing from the response")
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Column A custom column of a table, a header and a JSONPath expression evaluated
+// against each entry of the structured report, ie: CPU:.cpu.req
+type Column struct {
+	Header string
+	Path   string
+	jp     *jsonpath.JSONPath
+}
+
+// ParseColumns Parse a kubectl style custom columns spec, ie: NAME:.name,CPU:.cpu.req
+// Paths may omit the braces and leading dot, {.cpu.req}, .cpu.req and cpu.req are equal.
+func ParseColumns(spec string) ([]Column, error) {
+	var cols []Column
+	for _, part := range strings.Split(spec, ",") {
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 || len(kv[1]) == 0 {
+			return nil, fmt.Errorf("invalid custom column %q, use HEADER:.path", part)
+		}
+		path := strings.TrimSuffix(strings.TrimPrefix(kv[1], "{"), "}")
+		if !strings.HasPrefix(path, ".") {
+			path = "." + path
+		}
+		jp := jsonpath.New(kv[0]).AllowMissingKeys(true)
+		if err := jp.Parse("{" + path + "}"); err != nil {
+			return nil, fmt.Errorf("invalid custom column %q: %w", part, err)
+		}
+		cols = append(cols, Column{Header: kv[0], Path: path, jp: jp})
+	}
+	return cols, nil
+}
+
+// Convert a report entry into the generic maps and slices JSONPath walks
+// Whole numbers stay integers so bytes and millicores print in full.
+func generic(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var out interface{}
+	if err := d.Decode(&out); err != nil {
+		return nil, err
+	}
+	return numbers(out), nil
+}
+
+// Replace the json.Number values of a decoded document with int64 or float64
+func numbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = numbers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = numbers(e)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	}
+	return v
+}
+
+// Return the cell of a column for a report entry converted by generic, <none> when
+// the path matches nothing
+func (col Column) cell(data interface{}) (string, error) {
+	results, err := col.jp.FindResults(data)
+	if err != nil {
+		return "", fmt.Errorf("column %s: %w", col.Header, err)
+	}
+	var vals []string
+	for _, r := range results {
+		for _, v := range r {
+			vals = append(vals, fmt.Sprint(v.Interface()))
+		}
+	}
+	if len(vals) == 0 {
+		return "<none>", nil
+	}
+	return strings.Join(vals, ","), nil
+}
+
+// Return the entries of each list section of the report, the cluster as a single entry
+func (r *Report) sections() [][]interface{} {
+	var s [][]interface{}
+	add := func(n int, entry func(int) interface{}) {
+		var rows []interface{}
+		for i := 0; i < n; i++ {
+			rows = append(rows, entry(i))
+		}
+		if len(rows) > 0 {
+			s = append(s, rows)
+		}
+	}
+	add(len(r.Nodes), func(i int) interface{} { return r.Nodes[i] })
+	add(len(r.Namespaces), func(i int) interface{} { return r.Namespaces[i] })
+	add(len(r.Pending), func(i int) interface{} { return r.Pending[i] })
+	add(len(r.Pods), func(i int) interface{} { return r.Pods[i] })
+	if r.Cluster != nil {
+		add(1, func(int) interface{} { return r.Cluster })
+	}
+	return s
+}
+
+// PrintColumns Print a table of the given columns for each section of the report
+func (r *Report) PrintColumns(cols []Column, d Display) error {
+	var header []string
+	for _, col := range cols {
+		header = append(header, col.Header)
+	}
+	for i, entries := range r.sections() {
+		if i > 0 {
+			fmt.Println()
+		}
+		t := newTable(header...)
+		for _, e := range entries {
+			data, err := generic(e)
+			if err != nil {
+				return err
+			}
+			var row []cell
+			for _, col := range cols {
+				v, err := col.cell(data)
+				if err != nil {
+					return err
+				}
+				row = append(row, txt(v))
+			}
+			t.add(row...)
+		}
+		t.print(d)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseColumns(t *testing.T) {
+	cols, err := ParseColumns("NAME:.name,CPU:cpu.req,ZONE:{.zone}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 3 || cols[1].Header != "CPU" || cols[1].Path != ".cpu.req" || cols[2].Path != ".zone" {
+		t.Errorf("ParseColumns = %+v", cols)
+	}
+	for _, spec := range []string{"NAME", "NAME:", ":.name", "NAME:.name,CPU:.cpu["} {
+		if _, err := ParseColumns(spec); err == nil {
+			t.Errorf("ParseColumns(%q) accepted an invalid spec", spec)
+		}
+	}
+}
+
+func TestColumnCells(t *testing.T) {
+	n := testNode("node-1", "4", "8Gi", "110", corev1.Taint{Key: "node-role.kubernetes.io/infra", Effect: corev1.TaintEffectNoSchedule})
+	n.Labels[corev1.LabelInstanceTypeStable] = "m5.xlarge"
+	n.Labels[corev1.LabelFailureDomainBetaZone] = "us-east-1a"
+	n.Status.NodeInfo.KubeletVersion = "v1.28.2"
+	n.CreationTimestamp = metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	c := loadFake(t, n, testPod("web", "p1", "node-1", "1500m", "1Gi", true))
+
+	if got := c.Nodes["node-1"]; got.Version != "v1.28.2" || got.InstanceType != "m5.xlarge" || got.Zone != "us-east-1a" {
+		t.Errorf("node info = %q %q %q", got.Version, got.InstanceType, got.Zone)
+	}
+	cols, err := ParseColumns("NAME:.name,CPU:.cpu.req,MEM:.memory.avail,ZONE:.zone,CREATED:.created,TAINTS:.taints[*],GPU:.resources.gpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := c.Report(true, false, false, false)
+	want := []string{"node-1", "1500", "8589934592", "us-east-1a", "2026-01-02T03:04:05Z", "infra:NoSchedule", "<none>"}
+	data, err := generic(r.Nodes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, col := range cols {
+		got, err := col.cell(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want[i] {
+			t.Errorf("column %s = %q, want %q", col.Header, got, want[i])
+		}
+	}
+	if s := r.sections(); len(s) != 1 || len(s[0]) != 1 {
+		t.Errorf("sections = %v, want the node only", s)
+	}
+}
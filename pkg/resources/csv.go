@@ -0,0 +1,185 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Return the unit suffix of a resource's CSV headers, ie: _millicores
+func csvUnit(res string) string {
+	switch {
+	case res == string(corev1.ResourceCPU):
+		return "_millicores"
+	case byteResource(res):
+		return "_bytes"
+	}
+	return ""
+}
+
+// Return the CSV headers of a resource, one per figure
+// Usage is only included for cpu and memory when it was loaded from the metrics API.
+func (c *Clustermetrics) csvHeaders(res string, figures ...string) []string {
+	var s []string
+	for _, f := range figures {
+		unit := csvUnit(res)
+		if f == "utilization" || f == "overcommit" {
+			unit = "_percent"
+		}
+		s = append(s, res+"_"+f+unit)
+	}
+	if c.usageCol(res) {
+		s = append(s, res+"_used"+csvUnit(res))
+	}
+	return s
+}
+
+// Return the CSV cells of a resource matching the figures of csvHeaders
+func (c *Clustermetrics) csvCells(res string, r Restat, figures ...string) []string {
+	var s []string
+	for _, f := range figures {
+		var v int64
+		switch f {
+		case "requested":
+			v = r.Req
+		case "limit":
+			v = r.Limit
+		case "allocatable", "available":
+			v = r.Avail
+		case "capacity":
+			v = r.Cap
+		case "utilization":
+			v = r.Util
+		case "overcommit":
+			v = r.Overcommit
+		}
+		s = append(s, fmt.Sprint(v))
+	}
+	if c.usageCol(res) {
+		s = append(s, fmt.Sprint(r.Used))
+	}
+	return s
+}
+
+// PrintCSV Print the selected summaries as CSV, separated by comma
+// Values are raw numbers, millicores for cpu and bytes for memory, with the unit
+// in the header, ie: cpu_requested_millicores. Each summary is a table of its own
+// with a header row, separated from the next by an empty line.
+func (c *Clustermetrics) PrintCSV(comma rune, nodes bool, namespaces bool, cluster bool) error {
+	return c.writeCSV(os.Stdout, comma, nodes, namespaces, cluster)
+}
+
+// Write the selected summaries as CSV to w
+func (c *Clustermetrics) writeCSV(out io.Writer, comma rune, nodes bool, namespaces bool, cluster bool) error {
+	var tables [][][]string
+	// Grouping by a label rolls the node table up into one row per group
+	if nodes && len(c.GroupBy) > 0 {
+		figures := []string{"requested", "limit", "available", "capacity", "utilization", "overcommit"}
+		header := []string{"group", "nodes", "unschedulable_nodes"}
+		for _, res := range c.resourceNames() {
+			header = append(header, c.csvHeaders(res, figures...)...)
+		}
+		rows := [][]string{append(header, "pods_count", "pods_available", "pods_capacity", "pods_utilization_percent")}
+		groups := c.Groups()
+		for _, name := range c.SortedGroups(groups) {
+			g := groups[name]
+			row := []string{name, fmt.Sprint(g.Nodes), fmt.Sprint(g.Unsched)}
+			for _, res := range c.resourceNames() {
+				row = append(row, c.csvCells(res, resStat(res, g.Cpu, g.Mem, g.Extended), figures...)...)
+			}
+			rows = append(rows, append(row, fmt.Sprint(g.Pods.Inuse), fmt.Sprint(g.Pods.Avail), fmt.Sprint(g.Pods.Cap), fmt.Sprint(g.Pods.Util)))
+		}
+		tables = append(tables, rows)
+	} else if nodes {
+		figures := []string{"requested", "limit", "allocatable", "capacity", "utilization", "overcommit"}
+		header := []string{"node", "status", "role", "taints", "schedulable", "unschedulable_reasons"}
+		if len(c.LabelKey) > 0 {
+			header = append(header, "label")
+		}
+		for _, res := range c.resourceNames() {
+			header = append(header, c.csvHeaders(res, figures...)...)
+		}
+		rows := [][]string{append(header, "pods_count", "pods_allocatable", "pods_capacity", "pods_utilization_percent")}
+		for _, name := range c.SortedNodes() {
+			n := c.Nodes[name]
+			taints := append([]string{}, n.Taints...)
+			sort.Strings(taints)
+			row := []string{name, n.Status, n.Label, strings.Join(taints, ";"), fmt.Sprint(n.Sched), strings.Join(n.SchedReasons, ";")}
+			if len(c.LabelKey) > 0 {
+				row = append(row, c.NodeLabel(n))
+			}
+			for _, res := range c.resourceNames() {
+				row = append(row, c.csvCells(res, resStat(res, n.Cpu, n.Mem, n.Extended), figures...)...)
+			}
+			rows = append(rows, append(row, fmt.Sprint(n.Pods.Inuse), fmt.Sprint(n.Pods.Avail), fmt.Sprint(n.Pods.Cap), fmt.Sprint(n.Pods.Util)))
+		}
+		tables = append(tables, rows)
+	}
+	if namespaces {
+		figures := []string{"requested", "limit", "utilization", "overcommit"}
+		header := []string{"namespace"}
+		for _, res := range c.resourceNames() {
+			header = append(header, c.csvHeaders(res, figures...)...)
+		}
+		rows := [][]string{append(header, "pods_count", "pods_utilization_percent")}
+		for _, name := range c.SortedNamespaces() {
+			n := c.Namespaces[name]
+			row := []string{name}
+			for _, res := range c.resourceNames() {
+				row = append(row, c.csvCells(res, resStat(res, n.Cpu, n.Mem, n.Extended), figures...)...)
+			}
+			rows = append(rows, append(row, fmt.Sprint(n.Pods.Inuse), fmt.Sprint(n.Pods.Util)))
+		}
+		tables = append(tables, rows)
+	}
+	if cluster {
+		figures := []string{"requested", "limit", "available", "capacity", "utilization", "overcommit"}
+		var header, row []string
+		for _, res := range c.resourceNames() {
+			header = append(header, c.csvHeaders(res, figures...)...)
+			r := resStat(res, c.Cpu, c.Mem, c.Extended)
+			row = append(row, c.csvCells(res, r, figures...)...)
+		}
+		header = append(header, "pods_count", "pods_available", "pods_capacity", "pods_utilization_percent")
+		row = append(row, fmt.Sprint(c.Pods.Inuse), fmt.Sprint(c.Pods.Avail), fmt.Sprint(c.Pods.Cap), fmt.Sprint(c.Pods.Util))
+		tables = append(tables, [][]string{header, row})
+	}
+
+	w := csv.NewWriter(out)
+	w.Comma = comma
+	for i, rows := range tables {
+		if i > 0 {
+			if err := w.Write(nil); err != nil {
+				return err
+			}
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+	}
+	return w.Error()
+}
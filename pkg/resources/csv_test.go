@@ -0,0 +1,72 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	c := loadFake(t,
+		testNode("node-1", "4", "8Gi", "110"),
+		testPod("web", "p1", "node-1", "1500m", "1536Mi", true),
+	)
+	var b bytes.Buffer
+	if err := c.writeCSV(&b, ',', true, false, false); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %v, want a header and node-1", rows)
+	}
+	want := map[string]string{
+		"node":                       "node-1",
+		"cpu_requested_millicores":   "1500",
+		"cpu_allocatable_millicores": "4000",
+		"memory_requested_bytes":     "1610612736",
+		"memory_utilization_percent": "18",
+		"pods_count":                 "1",
+	}
+	for i, h := range rows[0] {
+		if v, ok := want[h]; ok && rows[1][i] != v {
+			t.Errorf("%s = %s, want %s", h, rows[1][i], v)
+		}
+		delete(want, h)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing columns %v in %v", want, rows[0])
+	}
+
+	// Every view is a table of its own, separated by an empty line
+	b.Reset()
+	if err := c.writeCSV(&b, '\t', true, true, true); err != nil {
+		t.Fatal(err)
+	}
+	tables := strings.Split(b.String(), "\n\n")
+	if len(tables) != 3 || !strings.HasPrefix(tables[1], "namespace\tcpu_requested_millicores") || !strings.HasPrefix(tables[2], "cpu_requested_millicores\t") {
+		t.Errorf("tsv = %q", b.String())
+	}
+}
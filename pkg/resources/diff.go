@@ -0,0 +1,263 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+)
+
+// Nodediff Change of a single node between two Clustermetrics objects
+// Resource values hold the after value minus the before value.
+type Nodediff struct {
+	Name          string   `json:"name"`
+	Change        string   `json:"change"`
+	SchedBefore   bool     `json:"schedulableBefore"`
+	SchedAfter    bool     `json:"schedulableAfter"`
+	TaintsAdded   []string `json:"taintsAdded,omitempty"`
+	TaintsRemoved []string `json:"taintsRemoved,omitempty"`
+	Cpu           Restat   `json:"cpu"`
+	Mem           Restat   `json:"memory"`
+	Pods          Imetric  `json:"pods"`
+}
+
+// Nsdiff Change of a single namespace between two Clustermetrics objects
+type Nsdiff struct {
+	Name   string  `json:"name"`
+	Change string  `json:"change"`
+	Cpu    Restat  `json:"cpu"`
+	Mem    Restat  `json:"memory"`
+	Pods   Imetric `json:"pods"`
+}
+
+// Clusterdiff Changes between two Clustermetrics objects
+// Only nodes and namespaces which changed are listed.
+type Clusterdiff struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Nodes      []Nodediff `json:"nodes"`
+	Namespaces []Nsdiff   `json:"namespaces"`
+	Cpu        Restat     `json:"cpu"`
+	Mem        Restat     `json:"memory"`
+	Pods       Imetric    `json:"pods"`
+	Display    Display    `json:"-"`
+}
+
+// Values of the Change fields
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Return the difference a - b of two resource statistics
+func subRestat(a Restat, b Restat) Restat {
+	return Restat{
+		Req:        a.Req - b.Req,
+		Limit:      a.Limit - b.Limit,
+		RawReq:     a.RawReq - b.RawReq,
+		RawLimit:   a.RawLimit - b.RawLimit,
+		Avail:      a.Avail - b.Avail,
+		Cap:        a.Cap - b.Cap,
+		Util:       a.Util - b.Util,
+		Overcommit: a.Overcommit - b.Overcommit,
+		Used:       a.Used - b.Used,
+	}
+}
+
+// Return the difference a - b of two simple metrics
+func subImetric(a Imetric, b Imetric) Imetric {
+	return Imetric{
+		Inuse: a.Inuse - b.Inuse,
+		Avail: a.Avail - b.Avail,
+		Cap:   a.Cap - b.Cap,
+		Util:  a.Util - b.Util,
+	}
+}
+
+// Report whether a resource difference holds an actual change in allocation
+// Utilization alone is not a change, it moves whenever the cluster size does.
+func (r Restat) moved() bool {
+	return r.Req != 0 || r.Limit != 0 || r.Avail != 0 || r.Cap != 0
+}
+
+// Return the entries of a missing from b
+func missing(a []string, b []string) []string {
+	var s []string
+	for _, x := range a {
+		if !contains(b, x) {
+			s = append(s, x)
+		}
+	}
+	sort.Strings(s)
+	return s
+}
+
+// Diff Compare two Clustermetrics objects, ie: loaded from snapshots taken at different times
+func Diff(before *Clustermetrics, after *Clustermetrics) *Clusterdiff {
+	d := &Clusterdiff{APIVersion: ReportAPIVersion, Kind: "Diff", Nodes: []Nodediff{}, Namespaces: []Nsdiff{}}
+	d.Cpu = subRestat(after.Cpu, before.Cpu)
+	d.Mem = subRestat(after.Mem, before.Mem)
+	d.Pods = subImetric(after.Pods, before.Pods)
+
+	// Collect the node names of both sides
+	var s []string
+	for n := range before.Nodes {
+		s = append(s, n)
+	}
+	for n := range after.Nodes {
+		if _, ok := before.Nodes[n]; !ok {
+			s = append(s, n)
+		}
+	}
+	sort.Strings(s)
+	for _, name := range s {
+		b, inBefore := before.Nodes[name]
+		a, inAfter := after.Nodes[name]
+		nd := Nodediff{Name: name, Change: Changed}
+		switch {
+		case !inBefore:
+			nd.Change = Added
+			b = NewNodemetrics()
+			b.Sched = a.Sched
+		case !inAfter:
+			nd.Change = Removed
+			a = NewNodemetrics()
+			a.Sched = b.Sched
+		}
+		nd.SchedBefore = b.Sched
+		nd.SchedAfter = a.Sched
+		// Compare every taint in full so a changed value or effect shows up
+		nd.TaintsAdded = missing(a.AllTaints, b.AllTaints)
+		nd.TaintsRemoved = missing(b.AllTaints, a.AllTaints)
+		nd.Cpu = subRestat(a.Cpu, b.Cpu)
+		nd.Mem = subRestat(a.Mem, b.Mem)
+		nd.Pods = subImetric(a.Pods, b.Pods)
+		if nd.Change == Changed && nd.SchedBefore == nd.SchedAfter && len(nd.TaintsAdded) == 0 && len(nd.TaintsRemoved) == 0 &&
+			!nd.Cpu.moved() && !nd.Mem.moved() && nd.Pods.Inuse == 0 && nd.Pods.Avail == 0 {
+			continue
+		}
+		d.Nodes = append(d.Nodes, nd)
+	}
+
+	// Same for the namespaces, which only change through the pods running in them
+	s = nil
+	for n := range before.Namespaces {
+		s = append(s, n)
+	}
+	for n := range after.Namespaces {
+		if _, ok := before.Namespaces[n]; !ok {
+			s = append(s, n)
+		}
+	}
+	sort.Strings(s)
+	for _, name := range s {
+		b, inBefore := before.Namespaces[name]
+		a, inAfter := after.Namespaces[name]
+		nd := Nsdiff{Name: name, Change: Changed}
+		switch {
+		case !inBefore:
+			nd.Change = Added
+			b = NewNsmetrics()
+		case !inAfter:
+			nd.Change = Removed
+			a = NewNsmetrics()
+		}
+		nd.Cpu = subRestat(a.Cpu, b.Cpu)
+		nd.Mem = subRestat(a.Mem, b.Mem)
+		nd.Pods = subImetric(a.Pods, b.Pods)
+		if nd.Change == Changed && nd.Cpu.Req == 0 && nd.Cpu.Limit == 0 && nd.Mem.Req == 0 && nd.Mem.Limit == 0 && nd.Pods.Inuse == 0 {
+			continue
+		}
+		d.Namespaces = append(d.Namespaces, nd)
+	}
+	return d
+}
+
+// Format the schedulability change of a node
+func schedChange(before bool, after bool) string {
+	yesno := map[bool]string{true: "yes", false: "no"}
+	if before == after {
+		return yesno[after]
+	}
+	return yesno[before] + "->" + yesno[after]
+}
+
+// Format the taint changes of a node
+func taintChange(added []string, removed []string) string {
+	var s []string
+	for _, t := range added {
+		s = append(s, "+"+t)
+	}
+	for _, t := range removed {
+		s = append(s, "-"+t)
+	}
+	return strings.Join(s, ",")
+}
+
+// PrintNodeDiff Print the changes of each node
+func (d *Clusterdiff) PrintNodeDiff() {
+	t := newTable("NODE", "CHANGE", "SCHED", "CPU REQ", "CPU LIM", "MEM REQ", "MEM LIM", "PODS", "TAINTS")
+	t.truncate("NODE", "TAINTS")
+	for _, n := range d.Nodes {
+		t.add(txt(n.Name), txt(n.Change), txt(schedChange(n.SchedBefore, n.SchedAfter)),
+			txt(utils.FmtDelta(n.Cpu.Req, utils.FmtMilli)), txt(utils.FmtDelta(n.Cpu.Limit, utils.FmtMilli)),
+			txt(utils.FmtDelta(n.Mem.Req, utils.FmtMem)), txt(utils.FmtDelta(n.Mem.Limit, utils.FmtMem)),
+			txt(utils.FmtDelta(n.Pods.Inuse, utils.FmtInt)), txt(taintChange(n.TaintsAdded, n.TaintsRemoved)))
+	}
+	t.print(d.Display)
+}
+
+// PrintNamespaceDiff Print the changes of each namespace
+func (d *Clusterdiff) PrintNamespaceDiff() {
+	t := newTable("NAMESPACE", "CHANGE", "CPU REQ", "CPU LIM", "MEM REQ", "MEM LIM", "PODS")
+	t.truncate("NAMESPACE")
+	for _, n := range d.Namespaces {
+		t.add(txt(n.Name), txt(n.Change),
+			txt(utils.FmtDelta(n.Cpu.Req, utils.FmtMilli)), txt(utils.FmtDelta(n.Cpu.Limit, utils.FmtMilli)),
+			txt(utils.FmtDelta(n.Mem.Req, utils.FmtMem)), txt(utils.FmtDelta(n.Mem.Limit, utils.FmtMem)),
+			txt(utils.FmtDelta(n.Pods.Inuse, utils.FmtInt)))
+	}
+	t.print(d.Display)
+}
+
+// PrintClusterDiff Print the changes of the cluster totals
+func (d *Clusterdiff) PrintClusterDiff() {
+	t := newTable("TOTAL CHANGE", "REQUESTED", "LIMITS", "AVAILABLE", "CAPACITY", "UTIL")
+	t.add(txt("CPU"), txt(utils.FmtDelta(d.Cpu.Req, utils.FmtCPU)), txt(utils.FmtDelta(d.Cpu.Limit, utils.FmtCPU)),
+		txt(utils.FmtDelta(d.Cpu.Avail, utils.FmtCPU)), txt(utils.FmtDelta(d.Cpu.Cap, utils.FmtCPU)), txt(utils.FmtDelta(d.Cpu.Util, utils.FmtPct)))
+	t.add(txt("MEMORY"), txt(utils.FmtDelta(d.Mem.Req, utils.FmtMem)), txt(utils.FmtDelta(d.Mem.Limit, utils.FmtMem)),
+		txt(utils.FmtDelta(d.Mem.Avail, utils.FmtMem)), txt(utils.FmtDelta(d.Mem.Cap, utils.FmtMem)), txt(utils.FmtDelta(d.Mem.Util, utils.FmtPct)))
+	t.add(txt("PODS"), txt(utils.FmtDelta(d.Pods.Inuse, utils.FmtInt)), txt(""),
+		txt(utils.FmtDelta(d.Pods.Avail, utils.FmtInt)), txt(utils.FmtDelta(d.Pods.Cap, utils.FmtInt)), txt(utils.FmtDelta(d.Pods.Util, utils.FmtPct)))
+	t.print(d.Display)
+}
+
+// PrintJSON Print the diff as indented JSON
+func (d *Clusterdiff) PrintJSON() error {
+	return printJSON(d)
+}
+
+// PrintYAML Print the diff as YAML
+func (d *Clusterdiff) PrintYAML() error {
+	return printYAML(d)
+}
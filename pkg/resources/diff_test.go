@@ -0,0 +1,125 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDiff(t *testing.T) {
+	taint := corev1.Taint{Key: "node-role.kubernetes.io/infra", Effect: corev1.TaintEffectNoSchedule}
+	before := loadItems(t,
+		[]*corev1.Node{
+			testNode("node1", "4", "8Gi", "110"),
+			testNode("node2", "4", "8Gi", "110"),
+			testNode("old", "2", "4Gi", "110"),
+		},
+		[]*corev1.Pod{
+			testPod("default", "web", "node1", "500m", "1Gi", true),
+			testPod("batch", "job", "node2", "1", "2Gi", true),
+			testPod("legacy", "app", "old", "1", "1Gi", true),
+		},
+	)
+	after := loadItems(t,
+		[]*corev1.Node{
+			testNode("node1", "4", "8Gi", "110"),
+			testNode("node2", "4", "8Gi", "110", taint),
+			testNode("new", "8", "16Gi", "110"),
+		},
+		[]*corev1.Pod{
+			testPod("default", "web", "node1", "500m", "1Gi", true),
+			testPod("default", "web2", "new", "1", "2Gi", true),
+			testPod("batch", "job", "node2", "1", "2Gi", true),
+		},
+	)
+	d := Diff(before, after)
+
+	var names []string
+	nodes := map[string]Nodediff{}
+	for _, n := range d.Nodes {
+		names = append(names, n.Name)
+		nodes[n.Name] = n
+	}
+	// node1 is unchanged and left out
+	if want := []string{"new", "node2", "old"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("changed nodes = %v, want %v", names, want)
+	}
+	if n := nodes["new"]; n.Change != Added || n.Cpu.Req != 1000 || n.Pods.Inuse != 1 || n.Cpu.Avail != 8000 {
+		t.Errorf("added node = %+v", n)
+	}
+	if n := nodes["old"]; n.Change != Removed || n.Cpu.Req != -1000 || n.Mem.Req != -1<<30 || n.Pods.Inuse != -1 {
+		t.Errorf("removed node = %+v", n)
+	}
+	n := nodes["node2"]
+	if n.Change != Changed || !n.SchedBefore || n.SchedAfter {
+		t.Errorf("node2 schedulability = %v -> %v, want true -> false", n.SchedBefore, n.SchedAfter)
+	}
+	if !reflect.DeepEqual(n.TaintsAdded, []string{"node-role.kubernetes.io/infra:NoSchedule"}) || len(n.TaintsRemoved) != 0 {
+		t.Errorf("node2 taints added %v removed %v", n.TaintsAdded, n.TaintsRemoved)
+	}
+
+	names = nil
+	ns := map[string]Nsdiff{}
+	for _, n := range d.Namespaces {
+		names = append(names, n.Name)
+		ns[n.Name] = n
+	}
+	// batch only moved in utilization because the cluster grew
+	if want := []string{"default", "legacy"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("changed namespaces = %v, want %v", names, want)
+	}
+	if n := ns["default"]; n.Change != Changed || n.Cpu.Req != 1000 || n.Mem.Req != 2<<30 || n.Pods.Inuse != 1 {
+		t.Errorf("default namespace = %+v", n)
+	}
+	if n := ns["legacy"]; n.Change != Removed || n.Cpu.Req != -1000 {
+		t.Errorf("legacy namespace = %+v", n)
+	}
+
+	if d.Cpu.Req != 0 || d.Mem.Req != 1<<30 || d.Pods.Inuse != 0 {
+		t.Errorf("cluster diff cpu %+v mem %+v pods %+v", d.Cpu, d.Mem, d.Pods)
+	}
+}
+
+func TestDiffTaintEffect(t *testing.T) {
+	noschedule := corev1.Taint{Key: "example.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+	noexecute := corev1.Taint{Key: "example.com/gpu", Value: "true", Effect: corev1.TaintEffectNoExecute}
+	before := loadItems(t, []*corev1.Node{testNode("node1", "4", "8Gi", "110", noschedule)}, nil)
+	after := loadItems(t, []*corev1.Node{testNode("node1", "4", "8Gi", "110", noexecute)}, nil)
+	d := Diff(before, after)
+	if len(d.Nodes) != 1 {
+		t.Fatalf("changed nodes = %+v, want node1", d.Nodes)
+	}
+	n := d.Nodes[0]
+	if !reflect.DeepEqual(n.TaintsAdded, []string{"example.com/gpu=true:NoExecute"}) || !reflect.DeepEqual(n.TaintsRemoved, []string{"example.com/gpu=true:NoSchedule"}) {
+		t.Errorf("node1 taints added %v removed %v", n.TaintsAdded, n.TaintsRemoved)
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	nodes := []*corev1.Node{testNode("node1", "4", "8Gi", "110")}
+	pods := []*corev1.Pod{testPod("default", "web", "node1", "500m", "1Gi", true)}
+	d := Diff(loadItems(t, nodes, pods), loadItems(t, nodes, pods))
+	if len(d.Nodes) != 0 || len(d.Namespaces) != 0 {
+		t.Errorf("identical snapshots reported changes: %+v", d)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Report whether a resource is tracked in the Extended maps rather than its own field
+func extended(name corev1.ResourceName) bool {
+	return name != corev1.ResourceCPU && name != corev1.ResourceMemory && name != corev1.ResourcePods
+}
+
+// Collect the allocatable and capacity of every extended resource of a node
+// ie: ephemeral-storage, hugepages-2Mi, nvidia.com/gpu
+func nodeExtended(node *corev1.Node) map[string]*Restat {
+	m := make(map[string]*Restat)
+	for name := range node.Status.Allocatable {
+		if extended(name) {
+			m[string(name)] = &Restat{Avail: quantity(node.Status.Allocatable, name)}
+		}
+	}
+	for name := range node.Status.Capacity {
+		if !extended(name) {
+			continue
+		}
+		r, ok := m[string(name)]
+		if !ok {
+			r = &Restat{}
+			m[string(name)] = r
+		}
+		r.Cap = quantity(node.Status.Capacity, name)
+	}
+	return m
+}
+
+// Compute the effective requests and limits of every extended resource a pod asks for
+func podExtended(pod *corev1.Pod) map[string]*Restat {
+	names := make(map[corev1.ResourceName]bool)
+	collect := func(rl corev1.ResourceList) {
+		for name := range rl {
+			if extended(name) {
+				names[name] = true
+			}
+		}
+	}
+	for _, con := range pod.Spec.InitContainers {
+		collect(con.Resources.Requests)
+		collect(con.Resources.Limits)
+	}
+	for _, con := range pod.Spec.Containers {
+		collect(con.Resources.Requests)
+		collect(con.Resources.Limits)
+	}
+	collect(pod.Spec.Overhead)
+	if len(names) == 0 {
+		return nil
+	}
+	m := make(map[string]*Restat)
+	for name := range names {
+		r := podRequests(pod, name)
+		m[string(name)] = &r
+	}
+	return m
+}
+
+// Add the extended resource statistics of src to dst, allocating dst when needed
+// Utilization is computed separately once all objects are collected.
+func addExtended(dst map[string]*Restat, src map[string]*Restat) map[string]*Restat {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]*Restat)
+	}
+	for name, r := range src {
+		d, ok := dst[name]
+		if !ok {
+			d = &Restat{}
+			dst[name] = d
+		}
+		d.Req += r.Req
+		d.Limit += r.Limit
+		d.RawReq += r.RawReq
+		d.RawLimit += r.RawLimit
+		d.Avail += r.Avail
+		d.Cap += r.Cap
+	}
+	return dst
+}
+
+// Return the cluster statistic of an extended resource, allocating it when needed
+func (c *Clustermetrics) extStat(name string) *Restat {
+	if c.Extended == nil {
+		c.Extended = make(map[string]*Restat)
+	}
+	r, ok := c.Extended[name]
+	if !ok {
+		r = &Restat{}
+		c.Extended[name] = r
+	}
+	return r
+}
+
+// Copy extended resource statistics into a map of values for the structured report
+func extendedReport(m map[string]*Restat) map[string]Restat {
+	if len(m) == 0 {
+		return nil
+	}
+	r := make(map[string]Restat)
+	for name, s := range m {
+		r[name] = *s
+	}
+	return r
+}
+
+// ExtendedNames Return the names of the extended resources found in the cluster
+func (c *Clustermetrics) ExtendedNames() []string {
+	var s []string
+	for name := range c.Extended {
+		s = append(s, name)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// Return a statistic of any resource from the cpu and memory fields or an Extended map
+func resStat(name string, cpu Restat, mem Restat, ext map[string]*Restat) Restat {
+	switch name {
+	case string(corev1.ResourceCPU):
+		return cpu
+	case string(corev1.ResourceMemory):
+		return mem
+	}
+	if r, ok := ext[name]; ok {
+		return *r
+	}
+	return Restat{}
+}
+
+// Report whether a resource is measured in bytes
+func byteResource(name string) bool {
+	return name == string(corev1.ResourceMemory) || name == string(corev1.ResourceEphemeralStorage) ||
+		strings.HasPrefix(name, corev1.ResourceHugePagesPrefix)
+}
+
+// Format an absolute amount of a resource, millicores for cpu, bytes as MiB/GiB and counts as is
+func fmtQuantity(name string, v int64) string {
+	switch {
+	case name == string(corev1.ResourceCPU):
+		return utils.FmtMilli(v)
+	case byteResource(name):
+		return utils.FmtMem(v)
+	}
+	return utils.FmtInt(v)
+}
+
+// Return the column title of a resource, ie: CPU, MEM, NVIDIA.COM/GPU
+func resTitle(name string) string {
+	switch name {
+	case string(corev1.ResourceCPU):
+		return "CPU"
+	case string(corev1.ResourceMemory):
+		return "MEM"
+	}
+	return strings.ToUpper(name)
+}
+
+// ValidateResources Check the resources selected for the summaries, ie: cpu,memory,nvidia.com/gpu
+// Pods are always shown and can not be selected, unknown names are rejected like thresholds.
+func ValidateResources(names []string) error {
+	for _, name := range names {
+		if name == string(corev1.ResourcePods) || !thresholdResource(name) {
+			return fmt.Errorf("invalid resource %q: use cpu, memory or an extended resource", name)
+		}
+	}
+	return nil
+}
+
+// Return the resources shown in the summaries, cpu and memory unless Resources is set
+func (c *Clustermetrics) resourceNames() []string {
+	if len(c.Resources) == 0 {
+		return []string{string(corev1.ResourceCPU), string(corev1.ResourceMemory)}
+	}
+	return c.Resources
+}
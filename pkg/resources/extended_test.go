@@ -0,0 +1,91 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"testing"
+)
+
+func TestLoadExtended(t *testing.T) {
+	// GPU nodes with local storage, and pods requesting both
+	gpus := quantities("nvidia.com/gpu", "4", "ephemeral-storage", "100Gi")
+	train := withRequests(testPod("ml", "train", "gpu-1", "1", "1Gi", true), quantities("nvidia.com/gpu", "3", "ephemeral-storage", "10Gi"))
+	infer := withRequests(testPod("ml", "infer", "gpu-2", "1", "1Gi", true), quantities("nvidia.com/gpu", "1", "ephemeral-storage", "10Gi"))
+	c := loadFake(t,
+		withAllocatable(testNode("gpu-1", "16", "64Gi", "110"), gpus),
+		withAllocatable(testNode("gpu-2", "16", "64Gi", "110"), gpus),
+		testNode("cpu-1", "8", "32Gi", "110"),
+		withLimits(train, quantities("nvidia.com/gpu", "3")),
+		withLimits(infer, quantities("nvidia.com/gpu", "1")),
+	)
+	if r := c.Nodes["gpu-1"].Extended["nvidia.com/gpu"]; r == nil || r.Req != 3 || r.Limit != 3 || r.Avail != 4 || r.Util != 75 {
+		t.Errorf("gpu-1 gpus = %+v, want req 3 limit 3 avail 4 util 75", r)
+	}
+	if r := c.Nodes["gpu-1"].Extended["ephemeral-storage"]; r == nil || r.Req != 10<<30 || r.Util != 10 {
+		t.Errorf("gpu-1 ephemeral-storage = %+v, want req 10Gi util 10", r)
+	}
+	if _, ok := c.Nodes["cpu-1"].Extended["nvidia.com/gpu"]; ok {
+		t.Error("cpu-1 has gpus")
+	}
+	if r := c.Namespaces["ml"].Extended["nvidia.com/gpu"]; r == nil || r.Req != 4 || r.Util != 50 {
+		t.Errorf("ml gpus = %+v, want req 4 util 50", r)
+	}
+	if r := c.Extended["nvidia.com/gpu"]; r == nil || r.Req != 4 || r.Avail != 8 || r.Cap != 8 || r.Util != 50 {
+		t.Errorf("cluster gpus = %+v, want req 4 avail 8 cap 8 util 50", r)
+	}
+	if got := c.ExtendedNames(); len(got) != 2 || got[0] != "ephemeral-storage" || got[1] != "nvidia.com/gpu" {
+		t.Errorf("ExtendedNames = %v", got)
+	}
+	for _, p := range c.Podlist {
+		if p.Name == "train" && p.Extended["nvidia.com/gpu"].Util != 75 {
+			t.Errorf("train gpu share = %d, want 75", p.Extended["nvidia.com/gpu"].Util)
+		}
+	}
+}
+
+func TestFmtQuantity(t *testing.T) {
+	tests := []struct {
+		name string
+		v    int64
+		want string
+	}{
+		{"cpu", 1500, "1500m"},
+		{"memory", 2 << 30, "2 GiB"},
+		{"ephemeral-storage", 512 << 20, "512 MiB"},
+		{"hugepages-2Mi", 1 << 30, "1 GiB"},
+		{"nvidia.com/gpu", 3, "3"},
+	}
+	for _, tt := range tests {
+		if got := fmtQuantity(tt.name, tt.v); got != tt.want {
+			t.Errorf("fmtQuantity(%s, %d) = %q, want %q", tt.name, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestValidateResources(t *testing.T) {
+	if err := ValidateResources([]string{"cpu", "memory", "ephemeral-storage", "hugepages-2Mi", "nvidia.com/gpu"}); err != nil {
+		t.Error(err)
+	}
+	for _, name := range []string{"bogus", "pods", "gpu", "nvidia.com/"} {
+		if err := ValidateResources([]string{"cpu", name}); err == nil {
+			t.Errorf("ValidateResources(%q) accepted an invalid resource", name)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Filter Limits the nodes and pods collected into a Clustermetrics object
+// Empty fields match everything. Whatever can be expressed as a list option
+// is pushed down to the API server, the rest is matched after listing.
+// ReadyOnly is not a selector: matching pods are still listed, it decides which
+// of them count. It is kept here as every loader (Load, the Watcher, snapshot
+// replay) already takes a Filter and has to apply it the same way.
+type Filter struct {
+	Namespaces   []string // Namespace names or glob patterns (ie: team-*)
+	Nodes        []string // Node names
+	NodeSelector string   // Label selector for nodes
+	PodSelector  string   // Label selector for pods
+	ReadyOnly    bool     // Count only pods with a ready container, see counts
+}
+
+// NodeListOptions List options for the Nodes list call
+func (f Filter) NodeListOptions() metav1.ListOptions {
+	opts := metav1.ListOptions{LabelSelector: f.NodeSelector}
+	if len(f.Nodes) == 1 {
+		opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", f.Nodes[0]).String()
+	}
+	return opts
+}
+
+// PodListOptions List options for the Pods list call
+func (f Filter) PodListOptions() metav1.ListOptions {
+	opts := metav1.ListOptions{LabelSelector: f.PodSelector}
+	if len(f.Nodes) == 1 {
+		opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", f.Nodes[0]).String()
+	}
+	return opts
+}
+
+// PodNamespace Namespace for the Pods list call
+// Only a single namespace without glob characters can be pushed down, for
+// anything else pods are listed in all namespaces and matched afterwards.
+func (f Filter) PodNamespace() string {
+	if len(f.Namespaces) == 1 && !strings.ContainsAny(f.Namespaces[0], "*?[") {
+		return f.Namespaces[0]
+	}
+	return metav1.NamespaceAll
+}
+
+// Parse the label selectors of the filter
+func (f Filter) selectors() (labels.Selector, labels.Selector, error) {
+	nodesel, err := labels.Parse(f.NodeSelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid node selector: %w", err)
+	}
+	podsel, err := labels.Parse(f.PodSelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid pod selector: %w", err)
+	}
+	for _, ns := range f.Namespaces {
+		if _, err := path.Match(ns, ""); err != nil {
+			return nil, nil, fmt.Errorf("invalid namespace pattern %q", ns)
+		}
+	}
+	return nodesel, podsel, nil
+}
+
+// Return true if the filter limits the set of nodes
+func (f Filter) nodeFiltered() bool {
+	return len(f.Nodes) > 0 || len(f.NodeSelector) > 0
+}
+
+// Return the nodes matching the filter
+func (f Filter) filterNodes(nodes []corev1.Node, sel labels.Selector) []corev1.Node {
+	if !f.nodeFiltered() {
+		return nodes
+	}
+	var s []corev1.Node
+	for _, n := range nodes {
+		if len(f.Nodes) > 0 && !contains(f.Nodes, n.Name) {
+			continue
+		}
+		if !sel.Matches(labels.Set(n.Labels)) {
+			continue
+		}
+		s = append(s, n)
+	}
+	return s
+}
+
+// Report whether a pod counts against the resources of its node
+// By default every pod bound to a node and not terminated counts, as the scheduler
+// has reserved its resources. With ReadyOnly only pods with a ready container count,
+// with the requests of all their containers.
+func (f Filter) counts(pod *corev1.Pod) bool {
+	if f.ReadyOnly {
+		for _, cons := range pod.Status.ContainerStatuses {
+			if cons.Ready {
+				return true
+			}
+		}
+		return false
+	}
+	if len(pod.Spec.NodeName) == 0 {
+		return false
+	}
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// Return the pods matching the filter
+// Pods not yet scheduled are dropped when the nodes are filtered, they are not
+// on any of the selected nodes.
+func (f Filter) filterPods(pods []corev1.Pod, sel labels.Selector) []corev1.Pod {
+	if len(f.Namespaces) == 0 && len(f.PodSelector) == 0 && !f.nodeFiltered() {
+		return pods
+	}
+	var s []corev1.Pod
+	for _, p := range pods {
+		if f.nodeFiltered() && len(p.Spec.NodeName) == 0 {
+			continue
+		}
+		if len(f.Namespaces) > 0 && !matchAny(f.Namespaces, p.Namespace) {
+			continue
+		}
+		if !sel.Matches(labels.Set(p.Labels)) {
+			continue
+		}
+		s = append(s, p)
+	}
+	return s
+}
+
+// Return true if s is in list
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Return true if s matches any of the glob patterns
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
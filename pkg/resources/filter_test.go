@@ -0,0 +1,130 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// filterFixture Two node pools with pods spread over a few namespaces
+func filterFixture() []runtime.Object {
+	gpu1 := testNode("gpu1", "8", "32Gi", "110")
+	gpu1.Labels["pool"] = "gpu"
+	gpu2 := testNode("gpu2", "8", "32Gi", "110")
+	gpu2.Labels["pool"] = "gpu"
+	web1 := testNode("web1", "4", "8Gi", "110")
+	web1.Labels["pool"] = "web"
+	train := testPod("team-ml", "train", "gpu1", "4", "16Gi", true)
+	train.Labels = map[string]string{"app": "train"}
+	serve := testPod("team-ml", "serve", "web1", "1", "2Gi", true)
+	serve.Labels = map[string]string{"app": "serve"}
+	return []runtime.Object{
+		gpu1, gpu2, web1, train, serve,
+		testPod("team-web", "frontend", "web1", "2", "2Gi", true),
+		testPod("kube-system", "dns", "gpu2", "100m", "128Mi", true),
+		testPod("kube-system", "pending", "", "1", "1Gi", false),
+	}
+}
+
+// The fake clientset ignores field selectors, so these tests also cover the
+// filtering done after listing
+func loadFiltered(t *testing.T, f Filter) *Clustermetrics {
+	t.Helper()
+	c := NewCluster()
+	c.Filter = f
+	if err := c.Load(fake.NewSimpleClientset(filterFixture()...)); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	return c
+}
+
+func TestFilterNodeSelector(t *testing.T) {
+	c := loadFiltered(t, Filter{NodeSelector: "pool=gpu"})
+	if len(c.Nodes) != 2 || c.Nodes["gpu1"] == nil || c.Nodes["gpu2"] == nil {
+		t.Fatalf("nodes = %v, want gpu1 and gpu2", c.Nodes)
+	}
+	// Cluster totals cover the selected pool only
+	if c.Cpu.Avail != 16000 || c.Cpu.Req != 4100 {
+		t.Errorf("cluster cpu = %+v, want avail 16000 req 4100", c.Cpu)
+	}
+	if _, ok := c.Namespaces["team-web"]; ok {
+		t.Error("namespace with pods only on other nodes was collected")
+	}
+}
+
+func TestFilterNodeName(t *testing.T) {
+	c := loadFiltered(t, Filter{Nodes: []string{"web1"}})
+	if len(c.Nodes) != 1 || c.Nodes["web1"] == nil {
+		t.Fatalf("nodes = %v, want web1", c.Nodes)
+	}
+	if c.Nodes["web1"].Pods.Inuse != 2 || c.Cpu.Req != 3000 {
+		t.Errorf("web1 pods = %d cpu req = %d, want 2 and 3000", c.Nodes["web1"].Pods.Inuse, c.Cpu.Req)
+	}
+}
+
+func TestFilterNamespaceGlob(t *testing.T) {
+	c := loadFiltered(t, Filter{Namespaces: []string{"team-*"}})
+	if len(c.Namespaces) != 2 || c.Namespaces["team-ml"] == nil || c.Namespaces["team-web"] == nil {
+		t.Fatalf("namespaces = %v, want team-ml and team-web", c.Namespaces)
+	}
+	// All nodes are still collected, only the pods are filtered
+	if len(c.Nodes) != 3 || c.Cpu.Req != 7000 {
+		t.Errorf("got %d nodes and cpu req %d, want 3 and 7000", len(c.Nodes), c.Cpu.Req)
+	}
+}
+
+func TestFilterPodSelector(t *testing.T) {
+	c := loadFiltered(t, Filter{Namespaces: []string{"team-ml"}, PodSelector: "app=train"})
+	if c.Namespaces["team-ml"].Pods.Inuse != 1 || c.Cpu.Req != 4000 {
+		t.Errorf("team-ml pods = %d cpu req = %d, want 1 and 4000", c.Namespaces["team-ml"].Pods.Inuse, c.Cpu.Req)
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	c := NewCluster()
+	c.Filter = Filter{NodeSelector: "pool=gpu,,"}
+	if err := c.Load(fake.NewSimpleClientset(filterFixture()...)); err == nil {
+		t.Error("expected an error for an invalid selector")
+	}
+	c = NewCluster()
+	c.Filter = Filter{Nodes: []string{"missing"}}
+	if err := c.Load(fake.NewSimpleClientset(filterFixture()...)); err == nil {
+		t.Error("expected an error when no nodes match")
+	}
+}
+
+func TestFilterListOptions(t *testing.T) {
+	f := Filter{Nodes: []string{"web1"}, NodeSelector: "pool=web", PodSelector: "app=serve", Namespaces: []string{"team-ml"}}
+	if o := f.NodeListOptions(); o.FieldSelector != "metadata.name=web1" || o.LabelSelector != "pool=web" {
+		t.Errorf("node list options = %+v", o)
+	}
+	if o := f.PodListOptions(); o.FieldSelector != "spec.nodeName=web1" || o.LabelSelector != "app=serve" {
+		t.Errorf("pod list options = %+v", o)
+	}
+	if ns := f.PodNamespace(); ns != "team-ml" {
+		t.Errorf("pod namespace = %q, want team-ml", ns)
+	}
+	if ns := (Filter{Namespaces: []string{"team-*"}}).PodNamespace(); ns != "" {
+		t.Errorf("glob pod namespace = %q, want all namespaces", ns)
+	}
+}
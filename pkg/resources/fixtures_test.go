@@ -0,0 +1,202 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+// Fixtures shared by the tests of this package. Nodes and pods are built with
+// testNode and testPod and adjusted with the with* helpers below.
+
+// quantities Build a resource list from name and quantity pairs, ie: "nvidia.com/gpu", "4"
+func quantities(pairs ...string) corev1.ResourceList {
+	rl := corev1.ResourceList{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		rl[corev1.ResourceName(pairs[i])] = resource.MustParse(pairs[i+1])
+	}
+	return rl
+}
+
+// cpuMem Build a resource list of cpu and memory
+func cpuMem(cpu string, mem string) corev1.ResourceList {
+	return quantities(string(corev1.ResourceCPU), cpu, string(corev1.ResourceMemory), mem)
+}
+
+// testNode Build a ready node with the given allocatable cpu, memory and pods
+func testNode(name string, cpu string, mem string, pods string, taints ...corev1.Taint) *corev1.Node {
+	rl := cpuMem(cpu, mem)
+	rl[corev1.ResourcePods] = resource.MustParse(pods)
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"node-role.kubernetes.io/worker": ""},
+		},
+		Spec: corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{
+			Capacity:    rl,
+			Allocatable: rl,
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+// withLabel Set a label on a node, ie: the node pool it belongs to
+func withLabel(n *corev1.Node, key string, value string) *corev1.Node {
+	n.Labels[key] = value
+	return n
+}
+
+// withAllocatable Add resources to the allocatable and capacity of a node, ie: GPUs
+func withAllocatable(n *corev1.Node, rl corev1.ResourceList) *corev1.Node {
+	for name, q := range rl {
+		n.Status.Allocatable[name] = q
+		n.Status.Capacity[name] = q
+	}
+	return n
+}
+
+// testPod Build a running pod with a single container requesting cpu and memory
+func testPod(ns string, name string, node string, cpu string, mem string, ready bool) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{{
+				Name:      "app",
+				Resources: corev1.ResourceRequirements{Requests: cpuMem(cpu, mem)},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", Ready: ready}},
+		},
+	}
+}
+
+// withRequests Add requests to the container of a pod built by testPod
+func withRequests(p *corev1.Pod, rl corev1.ResourceList) *corev1.Pod {
+	for name, q := range rl {
+		p.Spec.Containers[0].Resources.Requests[name] = q
+	}
+	return p
+}
+
+// withLimits Add limits to the container of a pod built by testPod
+func withLimits(p *corev1.Pod, rl corev1.ResourceList) *corev1.Pod {
+	res := &p.Spec.Containers[0].Resources
+	if res.Limits == nil {
+		res.Limits = corev1.ResourceList{}
+	}
+	for name, q := range rl {
+		res.Limits[name] = q
+	}
+	return p
+}
+
+// unscheduled Turn a pod into one the scheduler could not place, created age ago
+func unscheduled(p *corev1.Pod, age time.Duration) *corev1.Pod {
+	p.Spec.NodeName = ""
+	p.CreationTimestamp = metav1.NewTime(time.Now().Add(-age))
+	p.Status.Phase = corev1.PodPending
+	p.Status.ContainerStatuses = nil
+	p.Status.Conditions = []corev1.PodCondition{{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  "Unschedulable",
+		Message: "0/1 nodes are available: 1 Insufficient memory.",
+	}}
+	return p
+}
+
+// testContainer Build a container requesting cpu, with a cpu limit when lim is set
+func testContainer(name string, req string, lim string) corev1.Container {
+	con := corev1.Container{
+		Name: name,
+		Resources: corev1.ResourceRequirements{
+			Requests: quantities(string(corev1.ResourceCPU), req),
+		},
+	}
+	if len(lim) > 0 {
+		con.Resources.Limits = quantities(string(corev1.ResourceCPU), lim)
+	}
+	return con
+}
+
+// sidecar Turn an init container into a restartable sidecar
+func sidecar(con corev1.Container) corev1.Container {
+	always := corev1.ContainerRestartPolicyAlways
+	con.RestartPolicy = &always
+	return con
+}
+
+// loadFake Build a Clustermetrics object from a fake clientset holding the objects
+func loadFake(t *testing.T, objects ...runtime.Object) *Clustermetrics {
+	t.Helper()
+	c := NewCluster()
+	if err := c.Load(fake.NewSimpleClientset(objects...)); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	return c
+}
+
+// loadItems Build a Clustermetrics object from in-memory nodes and pods
+func loadItems(t *testing.T, nodes []*corev1.Node, pods []*corev1.Pod) *Clustermetrics {
+	t.Helper()
+	var nl []corev1.Node
+	for _, n := range nodes {
+		nl = append(nl, *n)
+	}
+	var pl []corev1.Pod
+	for _, p := range pods {
+		pl = append(pl, *p)
+	}
+	c := NewCluster()
+	if err := c.LoadItems(nl, pl); err != nil {
+		t.Fatalf("LoadItems returned error: %v", err)
+	}
+	return c
+}
+
+// fakeMetrics Build a fake metrics clientset serving the given node and pod metrics
+// The fake object tracker cannot map the metrics kinds to their resources, so
+// the list calls are answered by reactors instead.
+func fakeMetrics(nodes []metricsv1beta1.NodeMetrics, pods []metricsv1beta1.PodMetrics) *metricsfake.Clientset {
+	mc := &metricsfake.Clientset{}
+	mc.AddReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &metricsv1beta1.NodeMetricsList{Items: nodes}, nil
+	})
+	mc.AddReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &metricsv1beta1.PodMetricsList{Items: pods}, nil
+	})
+	return mc
+}
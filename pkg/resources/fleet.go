@@ -0,0 +1,164 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+)
+
+// Fleetmetrics Resource metrics for several clusters
+type Fleetmetrics struct {
+	Clusters map[string]*Clustermetrics
+	Errors   map[string]error
+	Cpu      Restat
+	Mem      Restat
+	Pods     Imetric
+	Warn     Thresholds
+	Crit     Thresholds
+	Display  Display
+	mu       sync.Mutex
+}
+
+// NewFleet constructor
+func NewFleet() *Fleetmetrics {
+	var f Fleetmetrics
+	f.Clusters = make(map[string]*Clustermetrics)
+	f.Errors = make(map[string]error)
+	return &f
+}
+
+// Add Adder for the Clusters, safe to call from several goroutines
+// A cluster which could not be loaded is recorded with its error instead.
+func (f *Fleetmetrics) Add(name string, c *Clustermetrics, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil {
+		f.Errors[name] = err
+		return
+	}
+	f.Clusters[name] = c
+	f.Cpu.Req += c.Cpu.Req
+	f.Cpu.Limit += c.Cpu.Limit
+	f.Cpu.Avail += c.Cpu.Avail
+	f.Cpu.Cap += c.Cpu.Cap
+	f.Mem.Req += c.Mem.Req
+	f.Mem.Limit += c.Mem.Limit
+	f.Mem.Avail += c.Mem.Avail
+	f.Mem.Cap += c.Mem.Cap
+	f.Pods.Inuse += c.Pods.Inuse
+	f.Pods.Avail += c.Pods.Avail
+	f.Pods.Cap += c.Pods.Cap
+	f.Cpu.Util = utils.CalcPct(f.Cpu.Avail, f.Cpu.Req)
+	f.Mem.Util = utils.CalcPct(f.Mem.Avail, f.Mem.Req)
+	f.Pods.Util = utils.CalcPct(f.Pods.Avail, f.Pods.Inuse)
+}
+
+// Return every cluster name, loaded or not, sorted alphabetically
+func (f *Fleetmetrics) names() []string {
+	var s []string
+	for n := range f.Clusters {
+		s = append(s, n)
+	}
+	for n := range f.Errors {
+		s = append(s, n)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// PrintFleetSummary Print utilization summary of each cluster and the fleet total
+// Clusters which could not be loaded are listed with their error after the table.
+func (f *Fleetmetrics) PrintFleetSummary() {
+	t := newTable("CLUSTER", "CPU REQ", "AVAILABLE", "CAPACITY", "UTIL", "MEM REQ", "AVAILABLE", "CAPACITY", "UTIL", "PODS", "AVAIL", "CAP", "UTIL")
+	t.truncate("CLUSTER")
+	var failed []string
+	for _, name := range f.names() {
+		// Unreachable clusters get an error row instead of aborting the whole run
+		if _, ok := f.Errors[name]; ok {
+			t.add(txt(name), txt("-"), txt("-"), txt("-"), txt("-"), txt("-"), txt("-"), txt("-"), txt("-"), txt("-"), txt("-"), txt("-"), txt("-"))
+			failed = append(failed, name)
+			continue
+		}
+		c := f.Clusters[name]
+		f.addRow(t, name, c.Cpu, c.Mem, c.Pods)
+	}
+	f.addRow(t, "TOTAL", f.Cpu, f.Mem, f.Pods)
+	t.print(f.Display)
+	for _, name := range failed {
+		fmt.Printf("Error: %s: %v\n", name, f.Errors[name])
+	}
+}
+
+// Add a single row to the fleet summary
+func (f *Fleetmetrics) addRow(t *table, name string, cpu Restat, mem Restat, pods Imetric) {
+	t.add(txt(name),
+		txt(utils.FmtCPU(cpu.Req)), txt(utils.FmtCPU(cpu.Avail)), txt(utils.FmtCPU(cpu.Cap)), levelCell("cpu", cpu.Util, f.Warn, f.Crit),
+		txt(utils.FmtMem(mem.Req)), txt(utils.FmtMem(mem.Avail)), txt(utils.FmtMem(mem.Cap)), levelCell("memory", mem.Util, f.Warn, f.Crit),
+		txt(fmt.Sprint(pods.Inuse)), txt(fmt.Sprint(pods.Avail)), txt(fmt.Sprint(pods.Cap)), levelCell("pods", pods.Util, f.Warn, f.Crit))
+}
+
+// FleetReport Machine readable view of a Fleetmetrics object
+type FleetReport struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Clusters   []FleetClusterReport `json:"clusters"`
+	Total      ClusterReport        `json:"total"`
+}
+
+// FleetClusterReport Report entry for a single cluster of the fleet
+type FleetClusterReport struct {
+	Name    string         `json:"name"`
+	Error   string         `json:"error,omitempty"`
+	Cluster *ClusterReport `json:"cluster,omitempty"`
+}
+
+// Report Build a structured report of the fleet
+func (f *Fleetmetrics) Report() *FleetReport {
+	r := &FleetReport{
+		APIVersion: ReportAPIVersion,
+		Kind:       "FleetReport",
+		Clusters:   []FleetClusterReport{},
+		Total:      ClusterReport{Cpu: f.Cpu, Mem: f.Mem, Pods: f.Pods},
+	}
+	for _, name := range f.names() {
+		e := FleetClusterReport{Name: name}
+		if err, ok := f.Errors[name]; ok {
+			e.Error = err.Error()
+		} else {
+			e.Cluster = f.Clusters[name].Report(false, false, true, false).Cluster
+		}
+		r.Clusters = append(r.Clusters, e)
+	}
+	return r
+}
+
+// PrintJSON Print the fleet report as indented JSON
+func (r *FleetReport) PrintJSON() error {
+	return printJSON(r)
+}
+
+// PrintYAML Print the fleet report as YAML
+func (r *FleetReport) PrintYAML() error {
+	return printYAML(r)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFleet(t *testing.T) {
+	a := loadFake(t, testNode("node1", "4", "8Gi", "100"), testPod("default", "web", "node1", "2", "2Gi", true))
+	b := loadFake(t, testNode("node1", "4", "8Gi", "100"), testPod("default", "web", "node1", "1", "6Gi", true))
+
+	f := NewFleet()
+	f.Add("prod", a, nil)
+	f.Add("staging", b, nil)
+	f.Add("lab", nil, errors.New("connection refused"))
+
+	if len(f.Clusters) != 2 || len(f.Errors) != 1 {
+		t.Fatalf("got %d clusters and %d errors, want 2 and 1", len(f.Clusters), len(f.Errors))
+	}
+	if f.Cpu.Req != 3000 || f.Cpu.Avail != 8000 || f.Cpu.Util != 37 {
+		t.Errorf("fleet cpu = %+v, want req 3000 avail 8000 util 37", f.Cpu)
+	}
+	if f.Mem.Util != 50 || f.Pods.Inuse != 2 || f.Pods.Avail != 200 {
+		t.Errorf("fleet mem util = %d pods = %+v, want 50, 2 in use and 200 available", f.Mem.Util, f.Pods)
+	}
+
+	r := f.Report()
+	if len(r.Clusters) != 3 || r.Clusters[0].Name != "lab" || r.Clusters[0].Error == "" || r.Clusters[0].Cluster != nil {
+		t.Errorf("unreachable cluster report = %+v", r.Clusters[0])
+	}
+	if r.Clusters[1].Cluster == nil || r.Clusters[1].Cluster.Cpu.Req != 2000 {
+		t.Errorf("prod cluster report = %+v", r.Clusters[1])
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NoGroup Group of the nodes without the label nodes are grouped by
+const NoGroup = "<none>"
+
+// Groupmetrics Resource metrics of a group of nodes, ie: a node pool
+// Available resources follow the cluster math: only schedulable nodes count,
+// plus whatever is already placed on the unschedulable ones.
+type Groupmetrics struct {
+	Nodes    int
+	Unsched  int
+	Cpu      Restat
+	Mem      Restat
+	Pods     Imetric
+	Extended map[string]*Restat
+}
+
+// NodeLabel Return the value shown in the LABEL column of a node
+// The node roles (node-role.kubernetes.io/*) unless c.LabelKey selects another label.
+func (c *Clustermetrics) NodeLabel(n *Nodemetrics) string {
+	if len(c.LabelKey) == 0 {
+		return n.Label
+	}
+	return n.Labels[c.LabelKey]
+}
+
+// Add the figures of a node to a group
+func (g *Groupmetrics) add(n *Nodemetrics) {
+	g.Nodes++
+	for _, p := range []struct{ dst, src *Restat }{{&g.Cpu, &n.Cpu}, {&g.Mem, &n.Mem}} {
+		p.dst.Req += p.src.Req
+		p.dst.Limit += p.src.Limit
+		p.dst.RawReq += p.src.RawReq
+		p.dst.RawLimit += p.src.RawLimit
+		p.dst.Cap += p.src.Cap
+		p.dst.Used += p.src.Used
+	}
+	g.Pods.Inuse += n.Pods.Inuse
+	g.Pods.Cap += n.Pods.Cap
+	g.Extended = addExtended(g.Extended, n.Extended)
+	if n.Sched {
+		g.Cpu.Avail += n.Cpu.Avail
+		g.Mem.Avail += n.Mem.Avail
+		g.Pods.Avail += n.Pods.Avail
+		return
+	}
+	// addExtended counted the allocatable of the unschedulable node, swap it for its requests
+	g.Unsched++
+	g.Cpu.Avail += n.Cpu.Req
+	g.Mem.Avail += n.Mem.Req
+	g.Pods.Avail += n.Pods.Inuse
+	for name, r := range n.Extended {
+		g.Extended[name].Avail += r.Req - r.Avail
+	}
+}
+
+// Groups Return the nodes rolled up by the value of the c.GroupBy label
+// Nodes without the label, or with an empty value, are grouped under NoGroup.
+func (c *Clustermetrics) Groups() map[string]*Groupmetrics {
+	groups := make(map[string]*Groupmetrics)
+	for _, n := range c.Nodes {
+		name := n.Labels[c.GroupBy]
+		if len(name) == 0 {
+			name = NoGroup
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &Groupmetrics{}
+			groups[name] = g
+		}
+		g.add(n)
+	}
+	for _, g := range groups {
+		for _, r := range append([]*Restat{&g.Cpu, &g.Mem}, extendedStats(g.Extended)...) {
+			r.Util = utils.CalcPct(r.Avail, r.Req)
+			r.Overcommit = utils.CalcPct(r.Avail, r.Limit)
+		}
+		g.Pods.Util = utils.CalcPct(g.Pods.Avail, g.Pods.Inuse)
+	}
+	return groups
+}
+
+// Return the statistics of a map of extended resources
+func extendedStats(m map[string]*Restat) []*Restat {
+	var s []*Restat
+	for _, r := range m {
+		s = append(s, r)
+	}
+	return s
+}
+
+// SortedGroups Return the group names in the order and number selected by c.Order
+// Groups are ranked like nodes, by the share of their available resources.
+// Sorting by role sorts them by name.
+func (c *Clustermetrics) SortedGroups(groups map[string]*Groupmetrics) []string {
+	var s []string
+	for name := range groups {
+		s = append(s, name)
+	}
+	sort.Strings(s)
+	return c.Order.sort(s, func(name string) float64 {
+		g := groups[name]
+		return c.Order.value(g.Cpu, g.Mem, g.Pods, true)
+	}, func(name string) string {
+		return name
+	})
+}
+
+// Format the node count of a group, ie: 3 (1 unschedulable)
+func groupNodes(g *Groupmetrics) string {
+	if g.Unsched == 0 {
+		return fmt.Sprint(g.Nodes)
+	}
+	return fmt.Sprintf("%d (%d unschedulable)", g.Nodes, g.Unsched)
+}
+
+// PrintGroupSummary Print the utilization summary of each group of nodes
+func (c *Clustermetrics) PrintGroupSummary() {
+	c.groupTable().print(c.Display)
+}
+
+// Return the column header of a label like kubectl -L, ie: NODEGROUP for eks.amazonaws.com/nodegroup
+func labelTitle(key string) string {
+	return strings.ToUpper(key[strings.LastIndex(key, "/")+1:])
+}
+
+// Build the group summary table, one row per value of the c.GroupBy label
+// AVAIL is the available figure of Groupmetrics, not the allocatable of the nodes.
+func (c *Clustermetrics) groupTable() *table {
+	title := labelTitle(c.GroupBy)
+	header := []string{title, "NODES"}
+	if c.alerting() {
+		header = append(header, "ALERT")
+	}
+	for _, res := range c.resourceNames() {
+		header = append(header, resTitle(res)+" REQ")
+		if limitCol(res) {
+			header = append(header, resTitle(res)+" LIM")
+		}
+		header = append(header, resTitle(res)+" AVAIL")
+		if c.usageCol(res) {
+			header = append(header, resTitle(res)+" USED")
+		}
+	}
+	t := newTable(append(header, "PODS")...)
+	t.truncate(title)
+
+	groups := c.Groups()
+	for _, name := range c.SortedGroups(groups) {
+		g := groups[name]
+		row := []cell{txt(name), txt(groupNodes(g))}
+		if c.alerting() {
+			row = append(row, alertCell(c.check("group", name, g.Cpu, g.Mem, g.Pods, g.Extended)))
+		}
+		for _, res := range c.resourceNames() {
+			r := resStat(res, g.Cpu, g.Mem, g.Extended)
+			// Groups without the resource, ie: no GPUs, show a dash rather than 0%
+			if extended(corev1.ResourceName(res)) && r.Avail == 0 && r.Req == 0 {
+				row = append(row, txt("-"), txt("-"))
+				continue
+			}
+			row = append(row, c.utilCell(res, r.Util))
+			if limitCol(res) {
+				row = append(row, txt(utils.FmtPct(r.Overcommit)))
+			}
+			row = append(row, txt(fmtQuantity(res, r.Avail)))
+			if c.usageCol(res) {
+				row = append(row, c.utilCell(res, utils.CalcPct(r.Avail, r.Used)))
+			}
+		}
+		row = append(row, c.utilCell(string(corev1.ResourcePods), g.Pods.Util))
+		t.addKeyed(name, row...)
+	}
+	return t
+}
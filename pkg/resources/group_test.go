@@ -0,0 +1,95 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// The label of the node pools in the group tests
+const nodegroup = "eks.amazonaws.com/nodegroup"
+
+func TestGroups(t *testing.T) {
+	cordoned := withLabel(testNode("gpu-2", "4", "8Gi", "110"), nodegroup, "gpu")
+	cordoned.Spec.Unschedulable = true
+	c := loadFake(t,
+		withLabel(testNode("general-1", "4", "8Gi", "110"), nodegroup, "general"),
+		withLabel(testNode("general-2", "4", "8Gi", "110"), nodegroup, "general"),
+		withLabel(testNode("gpu-1", "4", "8Gi", "110"), nodegroup, "gpu"),
+		cordoned,
+		testNode("unlabeled", "2", "8Gi", "110"),
+		testPod("default", "web", "general-1", "2", "1Gi", true),
+		testPod("default", "train", "gpu-2", "1", "1Gi", true),
+	)
+	c.GroupBy = nodegroup
+
+	groups := c.Groups()
+	if len(groups) != 3 {
+		t.Fatalf("groups = %v, want general, gpu and %s", groups, NoGroup)
+	}
+	general := groups["general"]
+	if general.Nodes != 2 || general.Cpu.Req != 2000 || general.Cpu.Avail != 8000 || general.Cpu.Util != 25 || general.Pods.Inuse != 1 {
+		t.Errorf("general = %+v", general)
+	}
+	// Only the schedulable node's allocatable counts, plus the requests placed on the cordoned one
+	gpu := groups["gpu"]
+	if gpu.Nodes != 2 || gpu.Unsched != 1 || gpu.Cpu.Avail != 5000 || gpu.Cpu.Cap != 8000 || gpu.Pods.Avail != 111 {
+		t.Errorf("gpu = %+v", gpu)
+	}
+	if groups[NoGroup].Nodes != 1 {
+		t.Errorf("%s = %+v", NoGroup, groups[NoGroup])
+	}
+
+	c.Order = Order{By: "cpu"}
+	if got := c.SortedGroups(groups); strings.Join(got, ",") != "general,gpu,"+NoGroup {
+		t.Errorf("SortedGroups(cpu) = %v", got)
+	}
+
+	var b bytes.Buffer
+	c.groupTable().write(&b, Display{})
+	if !strings.HasPrefix(b.String(), "NODEGROUP  NODES ") || !strings.Contains(b.String(), "2 (1 unschedulable)") || !strings.Contains(b.String(), "CPU AVAIL") {
+		t.Errorf("group table =\n%s", b.String())
+	}
+
+	// Thresholds add an ALERT column like the node table
+	c.Warn = Thresholds{"cpu": 25}
+	b.Reset()
+	c.groupTable().write(&b, Display{})
+	if !strings.Contains(b.String(), " ALERT ") || !strings.Contains(b.String(), "WARN cpu") {
+		t.Errorf("group table with thresholds =\n%s", b.String())
+	}
+}
+
+func TestNodeLabel(t *testing.T) {
+	c := loadFake(t, withLabel(testNode("general-1", "4", "8Gi", "110"), nodegroup, "general"))
+	n := c.Nodes["general-1"]
+	if got := c.NodeLabel(n); got != "worker" {
+		t.Errorf("NodeLabel = %q, want the role worker", got)
+	}
+	c.LabelKey = nodegroup
+	if got := c.NodeLabel(n); got != "general" {
+		t.Errorf("NodeLabel(nodegroup) = %q, want general", got)
+	}
+	if r := c.Report(true, false, false, false); r.Nodes[0].Role != "worker" || r.Nodes[0].Label != "general" {
+		t.Errorf("report role = %q label = %q, want worker and general", r.Nodes[0].Role, r.Nodes[0].Label)
+	}
+}
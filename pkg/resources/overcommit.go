@@ -0,0 +1,73 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+)
+
+// SortedOvercommit Return the node names ordered by memory limit overcommit, highest first
+// The Reverse and Top settings of c.Order apply.
+func (c *Clustermetrics) SortedOvercommit() []string {
+	var s []string
+	for n := range c.Nodes {
+		s = append(s, n)
+	}
+	sort.Slice(s, func(i, j int) bool {
+		a, b := fraction(c.Nodes[s[i]].Mem.Limit, c.Nodes[s[i]].Mem.Avail), fraction(c.Nodes[s[j]].Mem.Limit, c.Nodes[s[j]].Mem.Avail)
+		if a != b {
+			return a > b
+		}
+		return s[i] < s[j]
+	})
+	return arrange(c.Order, s)
+}
+
+// Count the pods on each node with an app container without a memory limit
+// Their memory use is bounded only by the node, whatever the overcommit ratio says.
+func (c *Clustermetrics) unlimitedPods() map[string]int {
+	m := make(map[string]int)
+	for _, p := range c.Podlist {
+		for _, con := range p.Containers {
+			if !con.Init && con.Mem.Limit == 0 {
+				m[p.Node]++
+				break
+			}
+		}
+	}
+	return m
+}
+
+// PrintOvercommitSummary Print the limits of each node against its allocatable resources
+// Nodes are ranked by memory limit overcommit, the nodes most at risk of OOM kills first.
+func (c *Clustermetrics) PrintOvercommitSummary() {
+	t := newTable("NODE", "LABEL", "MEM REQ", "MEM LIM", "MEM ALLOC", "OVERCOMMIT", "CPU LIM", "CPU ALLOC", "OVERCOMMIT", "NO MEM LIM")
+	t.truncate("NODE", "LABEL")
+	unlimited := c.unlimitedPods()
+	for _, name := range c.SortedOvercommit() {
+		n := c.Nodes[name]
+		t.add(txt(name), txt(c.NodeLabel(n)), txt(utils.FmtMem(n.Mem.Req)), txt(utils.FmtMem(n.Mem.Limit)), txt(utils.FmtMem(n.Mem.Avail)), txt(utils.FmtPct(n.Mem.Overcommit)),
+			txt(utils.FmtMilli(n.Cpu.Limit)), txt(utils.FmtMilli(n.Cpu.Avail)), txt(utils.FmtPct(n.Cpu.Overcommit)), txt(fmt.Sprint(unlimited[name])))
+	}
+	t.print(c.Display)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"testing"
+)
+
+func TestLoadLimits(t *testing.T) {
+	c := loadFake(t,
+		testNode("node-1", "4", "8Gi", "110"),
+		testNode("node-2", "4", "8Gi", "110"),
+		withLimits(testPod("web", "big", "node-1", "500m", "1Gi", true), cpuMem("2", "24Gi")),
+		withLimits(testPod("web", "small", "node-2", "500m", "1Gi", true), cpuMem("1", "4Gi")),
+		testPod("db", "unbounded", "node-2", "500m", "1Gi", true),
+	)
+	n := c.Nodes["node-1"]
+	if n.Mem.Req != 1<<30 || n.Mem.Util != 12 {
+		t.Errorf("node-1 memory requests = %d (%d%%), want 1Gi (12%%)", n.Mem.Req, n.Mem.Util)
+	}
+	if n.Mem.Limit != 24<<30 || n.Mem.Overcommit != 300 {
+		t.Errorf("node-1 memory limits = %d (%d%%), want 24Gi (300%%)", n.Mem.Limit, n.Mem.Overcommit)
+	}
+	if n.Cpu.Limit != 2000 || n.Cpu.Overcommit != 50 {
+		t.Errorf("node-1 cpu limits = %d (%d%%), want 2000 (50%%)", n.Cpu.Limit, n.Cpu.Overcommit)
+	}
+	if ns := c.Namespaces["web"]; ns.Mem.Limit != 28<<30 || ns.Mem.Overcommit != 175 {
+		t.Errorf("web memory limits = %d (%d%%), want 28Gi (175%%)", ns.Mem.Limit, ns.Mem.Overcommit)
+	}
+	if c.Mem.Limit != 28<<30 || c.Mem.Overcommit != 175 || c.Cpu.Overcommit != 37 {
+		t.Errorf("cluster limits = mem %d (%d%%) cpu %d%%, want 28Gi (175%%) cpu 37%%", c.Mem.Limit, c.Mem.Overcommit, c.Cpu.Overcommit)
+	}
+	if got := c.unlimitedPods(); got["node-1"] != 0 || got["node-2"] != 1 {
+		t.Errorf("pods without memory limits = %v, want node-2: 1", got)
+	}
+}
+
+func TestSortedOvercommit(t *testing.T) {
+	c := loadFake(t,
+		testNode("a", "4", "8Gi", "110"),
+		testNode("b", "4", "8Gi", "110"),
+		testNode("c", "4", "8Gi", "110"),
+		withLimits(testPod("web", "p1", "a", "500m", "1Gi", true), cpuMem("1", "4Gi")),
+		withLimits(testPod("web", "p2", "b", "500m", "1Gi", true), cpuMem("1", "16Gi")),
+	)
+	got := c.SortedOvercommit()
+	if len(got) != 3 || got[0] != "b" || got[1] != "a" || got[2] != "c" {
+		t.Errorf("SortedOvercommit = %v, want [b a c]", got)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Build the metrics of a pod waiting to be scheduled, nil for a terminated pod
+func pendingPod(pod *corev1.Pod) *Podmetrics {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return nil
+	}
+	p := &Podmetrics{
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		QoS:        qosClass(pod),
+		Phase:      podPhase(pod),
+		Created:    pod.CreationTimestamp.Time,
+		Cpu:        podRequests(pod, corev1.ResourceCPU),
+		Mem:        podRequests(pod, corev1.ResourceMemory),
+		Containers: containerRequests(pod),
+		Extended:   podExtended(pod),
+	}
+	// The scheduler explains why it could not place the pod in the PodScheduled condition
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+			p.Reason = cond.Reason
+			p.Message = cond.Message
+		}
+	}
+	return p
+}
+
+// PendingTotals Return the number of pending pods and the cpu/memory they request
+func (c *Clustermetrics) PendingTotals() Phasestat {
+	var t Phasestat
+	for _, p := range c.Pending {
+		t.Pods++
+		t.Cpu += p.Cpu.Req
+		t.Mem += p.Mem.Req
+	}
+	return t
+}
+
+// SortedPending Return the pending pods, oldest first
+func (c *Clustermetrics) SortedPending() []*Podmetrics {
+	pods := append([]*Podmetrics{}, c.Pending...)
+	sort.SliceStable(pods, func(i, j int) bool {
+		a, b := pods[i], pods[j]
+		switch {
+		case !a.Created.Equal(b.Created):
+			return a.Created.Before(b.Created)
+		case a.Namespace != b.Namespace:
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return pods
+}
+
+// PrintPendingSummary Print the pods waiting to be scheduled and why, oldest first
+func (c *Clustermetrics) PrintPendingSummary() {
+	t := newTable("NAMESPACE", "POD", "CPU REQ", "MEM REQ", "AGE", "MESSAGE")
+	t.truncate("NAMESPACE", "POD", "MESSAGE")
+	now := time.Now()
+	for _, p := range c.SortedPending() {
+		age := "<unknown>"
+		if !p.Created.IsZero() {
+			age = utils.FmtAge(now.Sub(p.Created))
+		}
+		// Scheduler messages may span several lines, keep the table on one
+		msg := strings.Join(strings.Fields(p.Message), " ")
+		if len(msg) == 0 {
+			msg = p.Reason
+		}
+		t.add(txt(p.Namespace), txt(p.Name), txt(utils.FmtMilli(p.Cpu.Req)), txt(utils.FmtMem(p.Mem.Req)), txt(age), txt(msg))
+	}
+	t.print(c.Display)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestLoadPending(t *testing.T) {
+	done := unscheduled(testPod("batch", "done", "", "1", "1Gi", false), time.Hour)
+	done.Status.Phase = corev1.PodSucceeded
+	c := loadFake(t,
+		testNode("node1", "4", "8Gi", "110"),
+		testPod("default", "web", "node1", "1", "1Gi", true),
+		unscheduled(testPod("default", "big", "", "2", "16Gi", false), time.Minute),
+		unscheduled(testPod("batch", "job", "", "500m", "1Gi", false), time.Hour),
+		done,
+	)
+	// Pending pods are kept apart, not under an empty node name
+	if _, ok := c.Nodes[""]; ok {
+		t.Error("pending pods collected under an empty node name")
+	}
+	if c.Cpu.Req != 1000 || c.Pods.Inuse != 1 {
+		t.Errorf("cluster cpu req = %d pods = %d, want 1000 and 1", c.Cpu.Req, c.Pods.Inuse)
+	}
+	if got := c.PendingTotals(); got != (Phasestat{Pods: 2, Cpu: 2500, Mem: 17 << 30}) {
+		t.Errorf("PendingTotals = %+v", got)
+	}
+	pods := c.SortedPending()
+	if len(pods) != 2 || pods[0].Name != "job" || pods[1].Name != "big" {
+		t.Fatalf("pending pods not sorted oldest first: %v, %v", pods[0].Name, pods[1].Name)
+	}
+	if pods[1].Reason != "Unschedulable" || pods[1].Message != "0/1 nodes are available: 1 Insufficient memory." {
+		t.Errorf("big reason %q message %q", pods[1].Reason, pods[1].Message)
+	}
+
+	r := c.Report(false, false, true, true)
+	if len(r.Pending) != 2 || r.Pending[0].Created == "" || r.Cluster.Pending.Pods != 2 {
+		t.Errorf("report pending = %+v cluster = %+v", r.Pending, r.Cluster.Pending)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Phasestat Requests of the pods counted in a single pod phase
+type Phasestat struct {
+	Pods int64 `json:"pods"`
+	Cpu  int64 `json:"cpu"`
+	Mem  int64 `json:"memory"`
+}
+
+// Return the phase of a pod, Unknown when the kubelet has not reported one yet
+func podPhase(pod *corev1.Pod) string {
+	if len(pod.Status.Phase) == 0 {
+		return string(corev1.PodUnknown)
+	}
+	return string(pod.Status.Phase)
+}
+
+// Add the phase statistics of src to dst, allocating dst when needed
+func addPhases(dst map[string]*Phasestat, src map[string]*Phasestat) map[string]*Phasestat {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]*Phasestat)
+	}
+	for phase, p := range src {
+		d, ok := dst[phase]
+		if !ok {
+			d = &Phasestat{}
+			dst[phase] = d
+		}
+		d.Pods += p.Pods
+		d.Cpu += p.Cpu
+		d.Mem += p.Mem
+	}
+	return dst
+}
+
+// Copy phase statistics into a map of values for the structured report
+func phaseReport(phases map[string]*Phasestat) map[string]Phasestat {
+	if len(phases) == 0 {
+		return nil
+	}
+	r := make(map[string]Phasestat)
+	for phase, p := range phases {
+		r[phase] = *p
+	}
+	return r
+}
+
+// Report whether any counted pod is in another phase than Running
+// The PHASES column is only shown in that case.
+func (c *Clustermetrics) phased() bool {
+	for phase := range c.Phases {
+		if phase != string(corev1.PodRunning) {
+			return true
+		}
+	}
+	return false
+}
+
+// Format the requests of the pods which are not Running, ie: "Pending 500m/1 GiB"
+func fmtPhases(phases map[string]*Phasestat) string {
+	var s []string
+	for phase := range phases {
+		if phase != string(corev1.PodRunning) {
+			s = append(s, phase)
+		}
+	}
+	sort.Strings(s)
+	for i, phase := range s {
+		p := phases[phase]
+		s[i] = phase + " " + utils.FmtMilli(p.Cpu) + "/" + utils.FmtMem(p.Mem)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,143 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"sort"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Determine the QoS class of a pod the way the kubelet does
+// The class recorded in the pod status is used when present.
+func qosClass(pod *corev1.Pod) string {
+	if len(pod.Status.QOSClass) > 0 {
+		return string(pod.Status.QOSClass)
+	}
+	var cons []corev1.Container
+	cons = append(cons, pod.Spec.InitContainers...)
+	cons = append(cons, pod.Spec.Containers...)
+	requests, limits, guaranteed := false, false, true
+	for _, con := range cons {
+		for _, r := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			req, hasReq := con.Resources.Requests[r]
+			lim, hasLim := con.Resources.Limits[r]
+			if hasReq && !req.IsZero() {
+				requests = true
+			}
+			if hasLim && !lim.IsZero() {
+				limits = true
+			}
+			// Requests default to limits, so a missing request still counts as equal
+			if !hasLim || lim.IsZero() || (hasReq && req.Cmp(lim) != 0) {
+				guaranteed = false
+			}
+		}
+	}
+	switch {
+	case !requests && !limits:
+		return string(corev1.PodQOSBestEffort)
+	case guaranteed:
+		return string(corev1.PodQOSGuaranteed)
+	}
+	return string(corev1.PodQOSBurstable)
+}
+
+// SortedPods Return the pods ordered by largest consumer first
+// Pods are ranked by the larger of their cpu and memory share of their node, then
+// by memory and cpu requested. Only pods bound to a node are listed, pods waiting
+// to be scheduled are in c.Pending (see SortedPending).
+// A sort key in c.Order ranks them by that resource, or by namespace and name.
+func (c *Clustermetrics) SortedPods() []*Podmetrics {
+	pods := append([]*Podmetrics{}, c.Podlist...)
+	sort.SliceStable(pods, func(i, j int) bool {
+		a, b := pods[i], pods[j]
+		switch c.Order.By {
+		case "":
+		case "name", "role":
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		default:
+			av := c.Order.value(a.Cpu, a.Mem, Imetric{Inuse: 1}, false)
+			bv := c.Order.value(b.Cpu, b.Mem, Imetric{Inuse: 1}, false)
+			if av != bv {
+				return av > bv
+			}
+		}
+		as := utils.MaxInt(int(a.Cpu.Util), int(a.Mem.Util))
+		bs := utils.MaxInt(int(b.Cpu.Util), int(b.Mem.Util))
+		switch {
+		case as != bs:
+			return as > bs
+		case a.Mem.Req != b.Mem.Req:
+			return a.Mem.Req > b.Mem.Req
+		case a.Cpu.Req != b.Cpu.Req:
+			return a.Cpu.Req > b.Cpu.Req
+		case a.Namespace != b.Namespace:
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return arrange(c.Order, pods)
+}
+
+// Format a container name for the pod summary, indented below its pod
+func containerName(con *Containermetrics) string {
+	if con.Init {
+		return "  init:" + con.Name
+	}
+	return "  " + con.Name
+}
+
+// PrintPodSummary Print the requests and limits of every pod, largest consumer first
+// With containers set, each pod is followed by a line per container.
+func (c *Clustermetrics) PrintPodSummary(containers bool) {
+	c.podTable(containers, func(*Podmetrics) bool { return true }).print(c.Display)
+}
+
+// Build the pod summary table of the pods matching keep, rows keyed by namespace/name
+func (c *Clustermetrics) podTable(containers bool, keep func(*Podmetrics) bool) *table {
+	t := newTable("NAMESPACE", "POD", "NODE", "QOS", "PHASE", "READY", "CPU REQ", "CPU LIM", "MEM REQ", "MEM LIM", "CPU SHARE", "MEM SHARE")
+	t.truncate("NAMESPACE", "POD", "NODE")
+	for _, p := range c.SortedPods() {
+		if !keep(p) {
+			continue
+		}
+		ready := "no"
+		if p.Ready {
+			ready = "yes"
+		}
+		t.addKeyed(p.Namespace+"/"+p.Name, txt(p.Namespace), txt(p.Name), txt(p.Node), txt(p.QoS), txt(p.Phase), txt(ready),
+			txt(utils.FmtMilli(p.Cpu.Req)), txt(utils.FmtMilli(p.Cpu.Limit)), txt(utils.FmtMem(p.Mem.Req)), txt(utils.FmtMem(p.Mem.Limit)),
+			txt(utils.FmtPct(p.Cpu.Util)), txt(utils.FmtPct(p.Mem.Util)))
+		if !containers {
+			continue
+		}
+		for _, con := range p.Containers {
+			t.add(txt(""), txt(containerName(con)), txt(""), txt(""), txt(""), txt(""),
+				txt(utils.FmtMilli(con.Cpu.Req)), txt(utils.FmtMilli(con.Cpu.Limit)), txt(utils.FmtMem(con.Mem.Req)), txt(utils.FmtMem(con.Mem.Limit)),
+				txt(utils.FmtPct(con.Cpu.Util)), txt(utils.FmtPct(con.Mem.Util)))
+		}
+	}
+	return t
+}
@@ -0,0 +1,101 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodlist(t *testing.T) {
+	c := loadFake(t,
+		testNode("node1", "4", "8Gi", "110"),
+		testNode("node2", "4", "8Gi", "110"),
+		testPod("default", "small", "node1", "100m", "512Mi", true),
+		testPod("default", "big", "node1", "1", "4Gi", true),
+		testPod("batch", "cpu", "node2", "3", "1Gi", true),
+		testPod("default", "crashing", "node1", "1", "1Gi", false),
+	)
+	pods := c.SortedPods()
+	var names []string
+	for _, p := range pods {
+		names = append(names, p.Name)
+	}
+	// Unready pods still hold their requests on the node
+	want := []string{"cpu", "big", "crashing", "small"}
+	if len(names) != len(want) {
+		t.Fatalf("pods = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("pods = %v, want %v", names, want)
+		}
+	}
+	big := pods[1]
+	if big.Node != "node1" || big.Namespace != "default" || big.QoS != "Burstable" {
+		t.Errorf("big = %+v", big)
+	}
+	if big.Cpu.Req != 1000 || big.Cpu.Util != 25 || big.Mem.Req != 4<<30 || big.Mem.Util != 50 {
+		t.Errorf("big cpu %+v mem %+v, want 1000m 25%% and 4Gi 50%%", big.Cpu, big.Mem)
+	}
+	if len(big.Containers) != 1 || big.Containers[0].Name != "app" || big.Containers[0].Mem.Util != 50 {
+		t.Errorf("big containers = %+v", big.Containers)
+	}
+
+	r := c.PodReport(false)
+	if len(r.Pods) != 4 || r.Pods[0].Name != "cpu" || r.Pods[0].Containers != nil {
+		t.Errorf("pod report = %+v", r.Pods)
+	}
+}
+
+func TestQoSClass(t *testing.T) {
+	pod := func(cons ...corev1.ResourceRequirements) *corev1.Pod {
+		p := &corev1.Pod{}
+		for _, r := range cons {
+			p.Spec.Containers = append(p.Spec.Containers, corev1.Container{Resources: r})
+		}
+		return p
+	}
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want string
+	}{
+		{"no resources", pod(corev1.ResourceRequirements{}), "BestEffort"},
+		{"requests only", pod(corev1.ResourceRequirements{Requests: cpuMem("1", "1Gi")}), "Burstable"},
+		{"equal", pod(corev1.ResourceRequirements{Requests: cpuMem("1", "1Gi"), Limits: cpuMem("1", "1Gi")}), "Guaranteed"},
+		{"limits only", pod(corev1.ResourceRequirements{Limits: cpuMem("1", "1Gi")}), "Guaranteed"},
+		{"higher limits", pod(corev1.ResourceRequirements{Requests: cpuMem("1", "1Gi"), Limits: cpuMem("2", "1Gi")}), "Burstable"},
+		{"mixed", pod(corev1.ResourceRequirements{Limits: cpuMem("1", "1Gi")}, corev1.ResourceRequirements{}), "Burstable"},
+	}
+	for _, tt := range tests {
+		if got := qosClass(tt.pod); got != tt.want {
+			t.Errorf("%s: qosClass = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// The class recorded by the kubelet wins
+	p := pod(corev1.ResourceRequirements{})
+	p.Status.QOSClass = corev1.PodQOSGuaranteed
+	if got := qosClass(p); got != "Guaranteed" {
+		t.Errorf("status qosClass = %s, want Guaranteed", got)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ReportAPIVersion Schema version of the structured report. Bump this whenever
+// a field is renamed, removed or changes meaning; adding new fields does not
+// change the version.
+// kutil/v2: req and limit are the effective pod figures, see podRequests
+const ReportAPIVersion = "kutil/v2"
+
+// ReportKind Kind of the structured report
+const ReportKind = "Report"
+
+// Report Machine readable view of a Clustermetrics object
+// CPU values are in millicores, memory values are in bytes and utilization
+// values are whole percentages. Used values are only meaningful when Usage is
+// true, meaning actual consumption was loaded from the metrics API.
+type Report struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Usage      bool              `json:"usage"`
+	Nodes      []NodeReport      `json:"nodes,omitempty"`
+	GroupBy    string            `json:"groupBy,omitempty"`
+	Groups     []GroupReport     `json:"groups,omitempty"`
+	Namespaces []NamespaceReport `json:"namespaces,omitempty"`
+	Cluster    *ClusterReport    `json:"cluster,omitempty"`
+	Pods       []PodReport       `json:"pods,omitempty"`
+	Pending    []PodReport       `json:"pending,omitempty"`
+}
+
+// NodeReport Report entry for a single node
+type NodeReport struct {
+	Name           string               `json:"name"`
+	Status         string               `json:"status"`
+	Role           string               `json:"role"`
+	Label          string               `json:"label,omitempty"`
+	Taints         []string             `json:"taints"`
+	Schedulable    bool                 `json:"schedulable"`
+	Unschedulable  []string             `json:"unschedulableReasons,omitempty"`
+	KubeletVersion string               `json:"kubeletVersion,omitempty"`
+	InstanceType   string               `json:"instanceType,omitempty"`
+	Zone           string               `json:"zone,omitempty"`
+	Created        string               `json:"created,omitempty"`
+	Cpu            Restat               `json:"cpu"`
+	Mem            Restat               `json:"memory"`
+	Pods           Imetric              `json:"pods"`
+	Phases         map[string]Phasestat `json:"phases,omitempty"`
+	Resources      map[string]Restat    `json:"resources,omitempty"`
+}
+
+// GroupReport Report entry for a group of nodes sharing the value of the GroupBy label
+type GroupReport struct {
+	Name          string            `json:"name"`
+	Nodes         int               `json:"nodes"`
+	Unschedulable int               `json:"unschedulable"`
+	Cpu           Restat            `json:"cpu"`
+	Mem           Restat            `json:"memory"`
+	Pods          Imetric           `json:"pods"`
+	Resources     map[string]Restat `json:"resources,omitempty"`
+}
+
+// NamespaceReport Report entry for a single namespace
+type NamespaceReport struct {
+	Name      string               `json:"name"`
+	Cpu       Restat               `json:"cpu"`
+	Mem       Restat               `json:"memory"`
+	Pods      Imetric              `json:"pods"`
+	Phases    map[string]Phasestat `json:"phases,omitempty"`
+	Resources map[string]Restat    `json:"resources,omitempty"`
+}
+
+// PodReport Report entry for a single pod
+// Util values hold the share of the node's allocatable resources.
+type PodReport struct {
+	Namespace  string            `json:"namespace"`
+	Name       string            `json:"name"`
+	Node       string            `json:"node"`
+	QoS        string            `json:"qosClass"`
+	Phase      string            `json:"phase"`
+	Ready      bool              `json:"ready"`
+	Cpu        Restat            `json:"cpu"`
+	Mem        Restat            `json:"memory"`
+	Containers []ContainerReport `json:"containers,omitempty"`
+	Created    string            `json:"created,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Message    string            `json:"message,omitempty"`
+	Resources  map[string]Restat `json:"resources,omitempty"`
+}
+
+// ContainerReport Report entry for a single container
+type ContainerReport struct {
+	Name string `json:"name"`
+	Init bool   `json:"init,omitempty"`
+	Cpu  Restat `json:"cpu"`
+	Mem  Restat `json:"memory"`
+}
+
+// ClusterReport Report entry for the cluster totals
+type ClusterReport struct {
+	Cpu       Restat               `json:"cpu"`
+	Mem       Restat               `json:"memory"`
+	Pods      Imetric              `json:"pods"`
+	Phases    map[string]Phasestat `json:"phases,omitempty"`
+	Resources map[string]Restat    `json:"resources,omitempty"`
+	Pending   Phasestat            `json:"pending"`
+}
+
+// Report Build a structured report holding the selected sections
+func (c *Clustermetrics) Report(nodes bool, namespaces bool, cluster bool, pending bool) *Report {
+	r := &Report{APIVersion: ReportAPIVersion, Kind: ReportKind, Usage: c.Usage}
+	if nodes {
+		r.Nodes = []NodeReport{}
+		for _, name := range c.SortedNodes() {
+			n := c.Nodes[name]
+			taints := append([]string{}, n.Taints...)
+			sort.Strings(taints)
+			nr := NodeReport{
+				Name:           name,
+				Status:         n.Status,
+				Role:           n.Label,
+				Taints:         taints,
+				Schedulable:    n.Sched,
+				Unschedulable:  n.SchedReasons,
+				KubeletVersion: n.Version,
+				InstanceType:   n.InstanceType,
+				Zone:           n.Zone,
+				Cpu:            n.Cpu,
+				Mem:            n.Mem,
+				Pods:           n.Pods,
+				Phases:         phaseReport(n.Phases),
+				Resources:      extendedReport(n.Extended),
+			}
+			// The value of the --label-key label, next to the roles rather than replacing them
+			if len(c.LabelKey) > 0 {
+				nr.Label = c.NodeLabel(n)
+			}
+			if !n.Created.IsZero() {
+				nr.Created = n.Created.UTC().Format(time.RFC3339)
+			}
+			r.Nodes = append(r.Nodes, nr)
+		}
+		// Nodes rolled up by a label, ie: the node pool
+		if len(c.GroupBy) > 0 {
+			r.GroupBy = c.GroupBy
+			groups := c.Groups()
+			for _, name := range c.SortedGroups(groups) {
+				g := groups[name]
+				r.Groups = append(r.Groups, GroupReport{Name: name, Nodes: g.Nodes, Unschedulable: g.Unsched, Cpu: g.Cpu, Mem: g.Mem, Pods: g.Pods, Resources: extendedReport(g.Extended)})
+			}
+		}
+	}
+	if namespaces {
+		r.Namespaces = []NamespaceReport{}
+		for _, name := range c.SortedNamespaces() {
+			n := c.Namespaces[name]
+			r.Namespaces = append(r.Namespaces, NamespaceReport{Name: name, Cpu: n.Cpu, Mem: n.Mem, Pods: n.Pods, Phases: phaseReport(n.Phases), Resources: extendedReport(n.Extended)})
+		}
+	}
+	if cluster {
+		r.Cluster = &ClusterReport{Cpu: c.Cpu, Mem: c.Mem, Pods: c.Pods, Phases: phaseReport(c.Phases), Resources: extendedReport(c.Extended), Pending: c.PendingTotals()}
+	}
+	if pending {
+		r.Pending = []PodReport{}
+		for _, p := range c.SortedPending() {
+			pr := PodReport{Namespace: p.Namespace, Name: p.Name, QoS: p.QoS, Phase: p.Phase, Cpu: p.Cpu, Mem: p.Mem, Reason: p.Reason, Message: p.Message, Resources: extendedReport(p.Extended)}
+			if !p.Created.IsZero() {
+				pr.Created = p.Created.UTC().Format(time.RFC3339)
+			}
+			r.Pending = append(r.Pending, pr)
+		}
+	}
+	return r
+}
+
+// PodReport Build a structured report of every pod, largest consumer first
+func (c *Clustermetrics) PodReport(containers bool) *Report {
+	r := &Report{APIVersion: ReportAPIVersion, Kind: ReportKind, Usage: c.Usage, Pods: []PodReport{}}
+	for _, p := range c.SortedPods() {
+		pr := PodReport{Namespace: p.Namespace, Name: p.Name, Node: p.Node, QoS: p.QoS, Phase: p.Phase, Ready: p.Ready, Cpu: p.Cpu, Mem: p.Mem, Resources: extendedReport(p.Extended)}
+		if containers {
+			for _, con := range p.Containers {
+				pr.Containers = append(pr.Containers, ContainerReport{Name: con.Name, Init: con.Init, Cpu: con.Cpu, Mem: con.Mem})
+			}
+		}
+		r.Pods = append(r.Pods, pr)
+	}
+	return r
+}
+
+// PrintJSON Print the report as indented JSON
+func (r *Report) PrintJSON() error {
+	return printJSON(r)
+}
+
+// PrintYAML Print the report as YAML
+func (r *Report) PrintYAML() error {
+	return printYAML(r)
+}
+
+// Print any report as indented JSON
+func printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// Print any report as YAML
+func printYAML(v interface{}) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(b))
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Return a resource quantity from a list, cpu in millicores and everything else in units
+func quantity(rl corev1.ResourceList, name corev1.ResourceName) int64 {
+	q, ok := rl[name]
+	if !ok {
+		return 0
+	}
+	if name == corev1.ResourceCPU {
+		return q.MilliValue()
+	}
+	return q.Value()
+}
+
+// Report whether an init container is a sidecar which keeps running next to the app containers
+func restartable(con *corev1.Container) bool {
+	return con.RestartPolicy != nil && *con.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+// Return the larger of two int64 values
+func max64(a int64, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// podRequests Compute the requests and limits of a pod for one resource
+// Req and Limit hold the effective figures the scheduler reserves: the larger of
+// the app containers (plus sidecars) and any single init container (plus the
+// sidecars started before it), plus the pod overhead of its runtime class.
+// RawReq and RawLimit hold the plain sum over the app containers.
+func podRequests(pod *corev1.Pod, name corev1.ResourceName) Restat {
+	var r Restat
+	for _, con := range pod.Spec.Containers {
+		r.RawReq += quantity(con.Resources.Requests, name)
+		r.RawLimit += quantity(con.Resources.Limits, name)
+	}
+	r.Req = r.RawReq
+	r.Limit = r.RawLimit
+
+	// Sidecars run for the life of the pod, so they add up with the app containers
+	// and with every init container started after them
+	var sideReq, sideLimit, initReq, initLimit int64
+	for i := range pod.Spec.InitContainers {
+		con := &pod.Spec.InitContainers[i]
+		req := quantity(con.Resources.Requests, name)
+		lim := quantity(con.Resources.Limits, name)
+		if restartable(con) {
+			r.Req += req
+			r.Limit += lim
+			sideReq += req
+			sideLimit += lim
+			initReq = max64(initReq, sideReq)
+			initLimit = max64(initLimit, sideLimit)
+			continue
+		}
+		initReq = max64(initReq, req+sideReq)
+		initLimit = max64(initLimit, lim+sideLimit)
+	}
+	r.Req = max64(r.Req, initReq)
+	r.Limit = max64(r.Limit, initLimit)
+
+	// Overhead is always reserved, but only raises a limit which is set
+	overhead := quantity(pod.Spec.Overhead, name)
+	r.Req += overhead
+	if r.Limit > 0 {
+		r.Limit += overhead
+	}
+	return r
+}
+
+// Return the requests and limits of each container of a pod, init containers first
+func containerRequests(pod *corev1.Pod) []*Containermetrics {
+	var cons []*Containermetrics
+	add := func(con *corev1.Container, init bool) {
+		cdata := &Containermetrics{Name: con.Name, Init: init}
+		cdata.Cpu.Req = quantity(con.Resources.Requests, corev1.ResourceCPU)
+		cdata.Cpu.Limit = quantity(con.Resources.Limits, corev1.ResourceCPU)
+		cdata.Mem.Req = quantity(con.Resources.Requests, corev1.ResourceMemory)
+		cdata.Mem.Limit = quantity(con.Resources.Limits, corev1.ResourceMemory)
+		cons = append(cons, cdata)
+	}
+	for i := range pod.Spec.InitContainers {
+		add(&pod.Spec.InitContainers[i], true)
+	}
+	for i := range pod.Spec.Containers {
+		add(&pod.Spec.Containers[i], false)
+	}
+	return cons
+}
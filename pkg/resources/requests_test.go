@@ -0,0 +1,117 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		init       []corev1.Container
+		containers []corev1.Container
+		overhead   string
+		want       Restat
+	}{
+		{
+			name:       "containers only",
+			containers: []corev1.Container{testContainer("a", "100m", "200m"), testContainer("b", "300m", "")},
+			want:       Restat{Req: 400, Limit: 200, RawReq: 400, RawLimit: 200},
+		},
+		{
+			name:       "larger init container",
+			init:       []corev1.Container{testContainer("migrate", "1", "2"), testContainer("small", "100m", "")},
+			containers: []corev1.Container{testContainer("app", "500m", "1")},
+			want:       Restat{Req: 1000, Limit: 2000, RawReq: 500, RawLimit: 1000},
+		},
+		{
+			name:       "smaller init container",
+			init:       []corev1.Container{testContainer("setup", "100m", "")},
+			containers: []corev1.Container{testContainer("app", "500m", "")},
+			want:       Restat{Req: 500, RawReq: 500},
+		},
+		{
+			// The sidecar adds to the app containers and to the init container started after it
+			name: "sidecar",
+			init: []corev1.Container{
+				sidecar(testContainer("proxy", "200m", "")),
+				testContainer("migrate", "1", ""),
+			},
+			containers: []corev1.Container{testContainer("app", "500m", "")},
+			want:       Restat{Req: 1200, RawReq: 500},
+		},
+		{
+			// An init container started before the sidecar does not overlap with it
+			name: "init before sidecar",
+			init: []corev1.Container{
+				testContainer("migrate", "1", ""),
+				sidecar(testContainer("proxy", "200m", "")),
+			},
+			containers: []corev1.Container{testContainer("app", "500m", "")},
+			want:       Restat{Req: 1000, RawReq: 500},
+		},
+		{
+			name:       "overhead",
+			containers: []corev1.Container{testContainer("app", "500m", "1"), testContainer("log", "100m", "")},
+			overhead:   "250m",
+			want:       Restat{Req: 850, Limit: 1250, RawReq: 600, RawLimit: 1000},
+		},
+		{
+			name:       "overhead without limits",
+			containers: []corev1.Container{testContainer("app", "500m", "")},
+			overhead:   "250m",
+			want:       Restat{Req: 750, RawReq: 500},
+		},
+	}
+	for _, tt := range tests {
+		pod := &corev1.Pod{Spec: corev1.PodSpec{InitContainers: tt.init, Containers: tt.containers}}
+		if len(tt.overhead) > 0 {
+			pod.Spec.Overhead = quantities(string(corev1.ResourceCPU), tt.overhead)
+		}
+		if got := podRequests(pod, corev1.ResourceCPU); got != tt.want {
+			t.Errorf("%s: podRequests = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadEffectiveRequests(t *testing.T) {
+	pod := testPod("default", "web", "node1", "500m", "1Gi", true)
+	pod.Spec.InitContainers = []corev1.Container{testContainer("migrate", "2", "")}
+	pod.Spec.Overhead = cpuMem("250m", "128Mi")
+	c := loadFake(t, testNode("node1", "4", "8Gi", "110"), pod)
+
+	n := c.Nodes["node1"]
+	if n.Cpu.Req != 2250 || n.Cpu.RawReq != 500 {
+		t.Errorf("node1 cpu = %+v, want req 2250 raw 500", n.Cpu)
+	}
+	if ns := c.Namespaces["default"]; ns.Mem.Req != 1<<30+128<<20 || ns.Mem.RawReq != 1<<30 {
+		t.Errorf("default mem = %+v, want req 1Gi+128Mi raw 1Gi", ns.Mem)
+	}
+	if c.Cpu.Req != 2250 || c.Cpu.RawReq != 500 {
+		t.Errorf("cluster cpu = %+v, want req 2250 raw 500", c.Cpu)
+	}
+	p := c.Podlist[0]
+	if len(p.Containers) != 2 || !p.Containers[0].Init || p.Containers[0].Cpu.Req != 2000 || p.Containers[1].Name != "app" {
+		t.Errorf("containers = %+v %+v", p.Containers[0], p.Containers[1])
+	}
+}
@@ -21,58 +21,120 @@ Full license text at: https://gnu.org/licenses/gpl-2.0.txt
 package resources
 
 import (
+	"errors"
 	"fmt"
-	"strings"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/jedrecord/kutil/pkg/utils"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
 // Restat A resource statistic to measure
+// Req and Limit are the effective figures the scheduler reserves, RawReq and
+// RawLimit the plain sum over the app containers. Overcommit is Limit as a
+// percentage of Avail, the way Util is for Req.
 type Restat struct {
-	Req   int64
-	Limit int64
-	Avail int64
-	Cap   int64
-	Util  int64
+	Req        int64 `json:"req"`
+	Limit      int64 `json:"limit"`
+	RawReq     int64 `json:"rawReq"`
+	RawLimit   int64 `json:"rawLimit"`
+	Avail      int64 `json:"avail"`
+	Cap        int64 `json:"cap"`
+	Util       int64 `json:"util"`
+	Overcommit int64 `json:"overcommit"`
+	Used       int64 `json:"used"`
 }
 
 // Nodemetrics Node resource metrics
 type Nodemetrics struct {
-	Taints []string
-	Sched  bool
-	Label  string
-	Status string
-	Cpu    Restat
-	Mem    Restat
-	Pods   Imetric
+	Taints       []string
+	AllTaints    []string
+	Sched        bool
+	SchedReasons []string
+	Label        string
+	Labels       map[string]string
+	Status       string
+	Version      string
+	InstanceType string
+	Zone         string
+	Created      time.Time
+	Cpu          Restat
+	Mem          Restat
+	Pods         Imetric
+	Phases       map[string]*Phasestat
+	Extended     map[string]*Restat
 }
 
 // Nsmetrics Namespace resource metrics
 type Nsmetrics struct {
+	Cpu      Restat
+	Mem      Restat
+	Pods     Imetric
+	Phases   map[string]*Phasestat
+	Extended map[string]*Restat
+}
+
+// Podmetrics Pod resource metrics
+// Util holds the share of the node's allocatable resources requested by the pod.
+// Reason and Message explain why a pending pod has not been scheduled.
+type Podmetrics struct {
+	Namespace  string
+	Name       string
+	Node       string
+	QoS        string
+	Phase      string
+	Ready      bool
+	Created    time.Time
+	Reason     string
+	Message    string
+	Cpu        Restat
+	Mem        Restat
+	Containers []*Containermetrics
+	Extended   map[string]*Restat
+}
+
+// Containermetrics Container resource metrics
+type Containermetrics struct {
+	Name string
+	Init bool
 	Cpu  Restat
 	Mem  Restat
-	Pods Imetric
 }
 
 // Clustermetrics Cluster resource metrics
+// Extended holds every resource other than cpu, memory and pods, ie: nvidia.com/gpu.
+// Resources selects the resources shown in the summaries, cpu and memory by default.
 type Clustermetrics struct {
 	Namespaces map[string]*Nsmetrics
 	Nodes      map[string]*Nodemetrics
+	Podlist    []*Podmetrics
+	Pending    []*Podmetrics
 	TaintLen   int
+	Usage      bool
+	Filter     Filter
 	Cpu        Restat
 	Mem        Restat
 	Pods       Imetric
+	Phases     map[string]*Phasestat
+	Extended   map[string]*Restat
+	Resources  []string
+	Order      Order
+	Warn       Thresholds
+	Crit       Thresholds
+	Display    Display
+	LabelKey   string
+	GroupBy    string
 }
 
 // Imetric Holder for simple metrics
 type Imetric struct {
-	Inuse int64
-	Avail int64
-	Cap   int64
-	Util  int64
+	Inuse int64 `json:"inuse"`
+	Avail int64 `json:"avail"`
+	Cap   int64 `json:"cap"`
+	Util  int64 `json:"util"`
 }
 
 // NewCluster constructor
@@ -96,16 +158,32 @@ func NewNsmetrics() *Nsmetrics {
 }
 
 // Load Retrieve kubernetes resource data into the Clustermetrics object
-func (c *Clustermetrics) Load(cs *kubernetes.Clientset) {
-	// Retrieve a list of nodes from the cluster as type nodelist
-	mynodes, err := cs.CoreV1().Nodes().List(metav1.ListOptions{})
+// Accepts any kubernetes.Interface so a fake clientset can be used in tests
+// Set the Filter field beforehand to limit the nodes and pods listed.
+func (c *Clustermetrics) Load(cs kubernetes.Interface) error {
+	s, err := TakeSnapshot(cs, c.Filter)
+	if err != nil {
+		return err
+	}
+	return c.LoadSnapshot(s)
+}
+
+// LoadItems Collect utilization data from node and pod objects into the Clustermetrics object
+// Used by Load and by callers which already hold the objects (ie: an informer cache)
+// Objects not matching the Clustermetrics Filter are skipped.
+func (c *Clustermetrics) LoadItems(nodes []corev1.Node, pods []corev1.Pod) error {
+	nodesel, podsel, err := c.Filter.selectors()
 	if err != nil {
-		utils.LogError("There was a problem connecting with the API")
+		return err
 	}
+	// Cluster totals are computed over the remaining nodes only
+	nodes = c.Filter.filterNodes(nodes, nodesel)
+	pods = c.Filter.filterPods(pods, podsel)
+
 	// Loop through the nodes to collect utilization data
-	if len(mynodes.Items) > 0 {
+	if len(nodes) > 0 {
 		// Node loop - Begin collecting node data
-		for _, mynode := range mynodes.Items {
+		for _, mynode := range nodes {
 			n := mynode.Name
 			// Loop over labels and assign node role
 			var role string
@@ -119,23 +197,30 @@ func (c *Clustermetrics) Load(cs *kubernetes.Clientset) {
 					role = role + pair[1]
 				}
 			}
-			// Loop over spec.taints["effect"] = "NoSchedule"
+			// Loop over the taints with a well known prefix (ie: node-role.kubernetes.io/master)
 			var nodetaints []string
 			var taintlen int = 0
-			var nodesched bool = true
 			for _, t := range mynode.Spec.Taints {
-				tkey := strings.Split(t.Key, "/")
-				if tkey[0] == "node-role.kubernetes.io" || tkey[0] == "node.kubernetes.io" {
-					s := tkey[1] + ":" + string(t.Effect)
+				if key := taintKey(t.Key); key != t.Key {
+					s := key + ":" + string(t.Effect)
 					nodetaints = append(nodetaints, s)
 					taintlen = utils.MaxInt(taintlen, len(s))
 				}
-				if t.Effect == "NoSchedule" || t.Effect == "NoExecute" {
-					nodesched = false
-				}
 			}
-			// Loop over status.conditions
+			// Every taint in full, ie: example.com/gpu=true:NoSchedule, for comparing snapshots
+			var alltaints []string
+			for _, t := range mynode.Spec.Taints {
+				alltaints = append(alltaints, fullTaint(t))
+			}
+			sort.Strings(alltaints)
+			// Cordoned, not ready and tainted nodes take no new pods
+			reasons := unschedulable(&mynode)
+			nodesched := len(reasons) == 0
+			// Loop over status.conditions, a not ready node shows NotReady first like kubectl
 			var nstatus string
+			if notReady(&mynode) {
+				nstatus = ReasonNotReady
+			}
 			for _, cond := range mynode.Status.Conditions {
 				if cond.Status == "True" {
 					if len(nstatus) > 0 {
@@ -149,8 +234,12 @@ func (c *Clustermetrics) Load(cs *kubernetes.Clientset) {
 				ndata.Taints = append(ndata.Taints, taint)
 			}
 			ndata.Label = role
+			ndata.Labels = mynode.Labels
+			ndata.AllTaints = alltaints
 			ndata.Sched = nodesched
+			ndata.SchedReasons = reasons
 			ndata.Status = nstatus
+			nodeInfo(&mynode, ndata)
 			cpuAvail := mynode.Status.Allocatable["cpu"]
 			memAvail := mynode.Status.Allocatable["memory"]
 			podsAvail := mynode.Status.Allocatable["pods"]
@@ -163,6 +252,14 @@ func (c *Clustermetrics) Load(cs *kubernetes.Clientset) {
 			ndata.Cpu.Cap = cpuCap.MilliValue()
 			ndata.Mem.Cap = memCap.Value()
 			ndata.Pods.Cap = podsCap.Value()
+			ndata.Extended = nodeExtended(&mynode)
+			for name, r := range ndata.Extended {
+				cr := c.extStat(name)
+				cr.Cap += r.Cap
+				if nodesched {
+					cr.Avail += r.Avail
+				}
+			}
 			c.UpdateNode(n, ndata)
 			c.Cpu.Cap += cpuCap.MilliValue()
 			c.Mem.Cap += memCap.Value()
@@ -176,82 +273,111 @@ func (c *Clustermetrics) Load(cs *kubernetes.Clientset) {
 			}
 		}
 	} else {
-		utils.LogError("No nodes discovered")
+		return errors.New("no nodes discovered")
 	}
 
-	// Retrieve a list of pods from the cluster as type podlist
-	mypods, err := cs.CoreV1().Pods("").List(metav1.ListOptions{})
-	if err != nil {
-		utils.LogError("There was a problem connecting with the API")
-	}
-	if len(mypods.Items) > 0 {
+	if len(pods) > 0 {
 		// Loop through the pods to collect utilization data
-		for _, mypod := range mypods.Items {
+		for _, mypod := range pods {
 			ns := mypod.Namespace
 			no := mypod.Spec.NodeName
+			// Pods not yet bound to a node are kept apart as pending demand
+			if len(no) == 0 {
+				if p := pendingPod(&mypod); p != nil {
+					c.Pending = append(c.Pending, p)
+				}
+				continue
+			}
+			// Pods bound to a node we did not list (ie: a node deleted since) are ignored
+			node, ok := c.Nodes[no]
+			if !ok {
+				continue
+			}
+			// Requests on unschedulable nodes are added back to the cluster's available resources
+			unsched := !node.Sched
 
-			// Initialize namespace and node data structs to hold the data
+			// Initialize namespace, node and pod data structs to hold the data
 			nsdata := NewNsmetrics()
 			ndata := NewNodemetrics()
-
-			// slice to hold the names of active containers in each pod
-			var activeContainers []string
-
-			// Loop through the status of each container in the pod
-			// We want to collect metrics from live containers only
+			pdata := &Podmetrics{Namespace: ns, Name: mypod.Name, Node: no, QoS: qosClass(&mypod), Phase: podPhase(&mypod)}
 			for _, cons := range mypod.Status.ContainerStatuses {
-				if cons.Ready == true {
-					activeContainers = append(activeContainers, cons.Name)
+				if cons.Ready {
+					pdata.Ready = true
 				}
 			}
-			// Loop through the container specs to collect cpu/memory requests and limits
-			for _, con := range mypod.Spec.Containers {
-				ok := false
-				// Make sure this is an active container
-				for _, a := range activeContainers {
-					if a == con.Name {
-						ok = true
-					}
-				}
-				if ok {
-					cpuReq := con.Resources.Requests["cpu"]
-					cpuLim := con.Resources.Limits["cpu"]
-					memReq := con.Resources.Requests["memory"]
-					memLim := con.Resources.Limits["memory"]
-					nsdata.Cpu.Req += cpuReq.MilliValue()
-					nsdata.Cpu.Limit += cpuLim.MilliValue()
-					nsdata.Mem.Req += memReq.Value()
-					nsdata.Mem.Limit += memLim.Value()
-					ndata.Cpu.Req += cpuReq.MilliValue()
-					ndata.Cpu.Limit += cpuLim.MilliValue()
-					ndata.Mem.Req += memReq.Value()
-					ndata.Mem.Req += memLim.Value()
-					c.Cpu.Req += cpuReq.MilliValue()
-					c.Cpu.Limit += cpuLim.MilliValue()
-					c.Mem.Req += memReq.Value()
-					c.Mem.Limit += memLim.Value()
-					if c.Nodes[no].Sched == false {
-						c.Cpu.Avail += cpuReq.MilliValue()
-						c.Mem.Avail += memReq.Value()
-					}
+
+			// Pods count with their effective requests and limits, including init
+			// containers and runtime overhead. See Filter.counts for which pods count.
+			if c.Filter.counts(&mypod) {
+				cpu := podRequests(&mypod, corev1.ResourceCPU)
+				mem := podRequests(&mypod, corev1.ResourceMemory)
+				cpuReq, cpuLim := cpu.Req, cpu.Limit
+				memReq, memLim := mem.Req, mem.Limit
+				nsdata.Cpu.Req += cpuReq
+				nsdata.Cpu.Limit += cpuLim
+				nsdata.Mem.Req += memReq
+				nsdata.Mem.Limit += memLim
+				ndata.Cpu.Req += cpuReq
+				ndata.Cpu.Limit += cpuLim
+				ndata.Mem.Req += memReq
+				ndata.Mem.Limit += memLim
+				c.Cpu.Req += cpuReq
+				c.Cpu.Limit += cpuLim
+				c.Mem.Req += memReq
+				c.Mem.Limit += memLim
+				if unsched {
+					c.Cpu.Avail += cpuReq
+					c.Mem.Avail += memReq
 				}
-			}
-			// If we've got at least 1 active container, add this pod to our pod stats
-			if len(activeContainers) > 0 {
+
+				// Keep the plain container sums next to the effective figures
+				nsdata.Cpu.RawReq += cpu.RawReq
+				nsdata.Cpu.RawLimit += cpu.RawLimit
+				nsdata.Mem.RawReq += mem.RawReq
+				nsdata.Mem.RawLimit += mem.RawLimit
+				ndata.Cpu.RawReq += cpu.RawReq
+				ndata.Cpu.RawLimit += cpu.RawLimit
+				ndata.Mem.RawReq += mem.RawReq
+				ndata.Mem.RawLimit += mem.RawLimit
+				c.Cpu.RawReq += cpu.RawReq
+				c.Cpu.RawLimit += cpu.RawLimit
+				c.Mem.RawReq += mem.RawReq
+				c.Mem.RawLimit += mem.RawLimit
+
 				nsdata.Pods.Inuse++
 				ndata.Pods.Inuse++
 				c.Pods.Inuse++
-				if c.Nodes[no].Sched == false {
+				if unsched {
 					c.Pods.Avail++
 				}
+				// Break the requests down by pod phase
+				phase := map[string]*Phasestat{pdata.Phase: {Pods: 1, Cpu: cpuReq, Mem: memReq}}
+				nsdata.Phases = addPhases(nil, phase)
+				ndata.Phases = addPhases(nil, phase)
+				c.Phases = addPhases(c.Phases, phase)
+
+				// Extended resources such as GPUs are added up the same way
+				ext := podExtended(&mypod)
+				nsdata.Extended = addExtended(nil, ext)
+				ndata.Extended = addExtended(nil, ext)
+				c.Extended = addExtended(c.Extended, ext)
+				if unsched {
+					for name, r := range ext {
+						c.extStat(name).Avail += r.Req
+					}
+				}
+
+				pdata.Cpu = cpu
+				pdata.Mem = mem
+				pdata.Extended = ext
+				pdata.Containers = containerRequests(&mypod)
+				c.Podlist = append(c.Podlist, pdata)
 			}
 			// These update functions take the structs we just collected and update
 			// the clustermetrics object (which is also as struct)
 			c.UpdateNamespace(ns, nsdata)
 			c.UpdateNode(no, ndata)
 		}
-	} else {
-		utils.LogError("No pods discovered")
 	}
 
 	// Calculate totals for namespaces
@@ -264,6 +390,12 @@ func (c *Clustermetrics) Load(cs *kubernetes.Clientset) {
 		nsdata.Mem.Util = mu
 		nsdata.Pods.Util = pu
 		c.UpdateNamespace(n, nsdata)
+		m.Cpu.Overcommit = utils.CalcPct(c.Cpu.Avail, m.Cpu.Limit)
+		m.Mem.Overcommit = utils.CalcPct(c.Mem.Avail, m.Mem.Limit)
+		for name, r := range m.Extended {
+			r.Util = utils.CalcPct(c.extStat(name).Avail, r.Req)
+			r.Overcommit = utils.CalcPct(c.extStat(name).Avail, r.Limit)
+		}
 	}
 
 	// Calculate totals for nodes
@@ -276,6 +408,29 @@ func (c *Clustermetrics) Load(cs *kubernetes.Clientset) {
 		ndata.Mem.Util = mu
 		ndata.Pods.Util = pu
 		c.UpdateNode(n, ndata)
+		m.Cpu.Overcommit = utils.CalcPct(m.Cpu.Avail, m.Cpu.Limit)
+		m.Mem.Overcommit = utils.CalcPct(m.Mem.Avail, m.Mem.Limit)
+		for _, r := range m.Extended {
+			r.Util = utils.CalcPct(r.Avail, r.Req)
+			r.Overcommit = utils.CalcPct(r.Avail, r.Limit)
+		}
+	}
+
+	// Calculate each pod's share of its node
+	for _, p := range c.Podlist {
+		n, ok := c.Nodes[p.Node]
+		if !ok {
+			continue
+		}
+		p.Cpu.Util = utils.CalcPct(n.Cpu.Avail, p.Cpu.Req)
+		p.Mem.Util = utils.CalcPct(n.Mem.Avail, p.Mem.Req)
+		for _, con := range p.Containers {
+			con.Cpu.Util = utils.CalcPct(n.Cpu.Avail, con.Cpu.Req)
+			con.Mem.Util = utils.CalcPct(n.Mem.Avail, con.Mem.Req)
+		}
+		for name, r := range p.Extended {
+			r.Util = utils.CalcPct(resStat(name, n.Cpu, n.Mem, n.Extended).Avail, r.Req)
+		}
 	}
 
 	// Calculate totals for the cluster
@@ -285,6 +440,13 @@ func (c *Clustermetrics) Load(cs *kubernetes.Clientset) {
 	c.Cpu.Util = cu
 	c.Mem.Util = mu
 	c.Pods.Util = pu
+	c.Cpu.Overcommit = utils.CalcPct(c.Cpu.Avail, c.Cpu.Limit)
+	c.Mem.Overcommit = utils.CalcPct(c.Mem.Avail, c.Mem.Limit)
+	for _, r := range c.Extended {
+		r.Util = utils.CalcPct(r.Avail, r.Req)
+		r.Overcommit = utils.CalcPct(r.Avail, r.Limit)
+	}
+	return nil
 }
 
 // UpdateNamespace Adder for the Namespaces
@@ -294,7 +456,13 @@ func (c *Clustermetrics) UpdateNamespace(name string, metrics *Nsmetrics) {
 		met.Cpu.Limit += metrics.Cpu.Limit
 		met.Mem.Req += metrics.Mem.Req
 		met.Mem.Limit += metrics.Mem.Limit
+		met.Cpu.RawReq += metrics.Cpu.RawReq
+		met.Cpu.RawLimit += metrics.Cpu.RawLimit
+		met.Mem.RawReq += metrics.Mem.RawReq
+		met.Mem.RawLimit += metrics.Mem.RawLimit
 		met.Pods.Inuse += metrics.Pods.Inuse
+		met.Phases = addPhases(met.Phases, metrics.Phases)
+		met.Extended = addExtended(met.Extended, metrics.Extended)
 		if metrics.Cpu.Util > 0 {
 			met.Cpu.Util = metrics.Cpu.Util
 		}
@@ -318,7 +486,13 @@ func (c *Clustermetrics) UpdateNode(name string, metrics *Nodemetrics) {
 		met.Cpu.Limit += metrics.Cpu.Limit
 		met.Mem.Req += metrics.Mem.Req
 		met.Mem.Limit += metrics.Mem.Limit
+		met.Cpu.RawReq += metrics.Cpu.RawReq
+		met.Cpu.RawLimit += metrics.Cpu.RawLimit
+		met.Mem.RawReq += metrics.Mem.RawReq
+		met.Mem.RawLimit += metrics.Mem.RawLimit
 		met.Pods.Inuse += metrics.Pods.Inuse
+		met.Phases = addPhases(met.Phases, metrics.Phases)
+		met.Extended = addExtended(met.Extended, metrics.Extended)
 
 		// Node metrics set when looping through nodes
 		met.Cpu.Avail += metrics.Cpu.Avail
@@ -328,9 +502,13 @@ func (c *Clustermetrics) UpdateNode(name string, metrics *Nodemetrics) {
 		if len(metrics.Label) > 0 {
 			met.Label = metrics.Label
 		}
+		if metrics.Labels != nil {
+			met.Labels = metrics.Labels
+		}
 		if len(metrics.Status) > 0 {
-			met.Status = metrics.Label
+			met.Status = metrics.Status
 			met.Sched = metrics.Sched
+			met.SchedReasons = metrics.SchedReasons
 		}
 		if metrics.Cpu.Util > 0 {
 			met.Cpu.Util = metrics.Cpu.Util
@@ -352,119 +530,197 @@ func (c *Clustermetrics) UpdateNode(name string, metrics *Nodemetrics) {
 	}
 }
 
-// Return the length of the longest entry in a list
-func (c *Clustermetrics) maxW(field string, min int) int {
-	var w int
-	switch field {
-	case "name":
-		for n := range c.Nodes {
-			w = utils.MaxInt(w, len(n))
-		}
-	case "status":
-		for _, m := range c.Nodes {
-			w = utils.MaxInt(w, len(m.Status))
-		}
-	case "label":
-		for _, m := range c.Nodes {
-			w = utils.MaxInt(w, len(m.Label))
-		}
-	case "taints":
-		for _, m := range c.Nodes {
-			w = utils.MaxInt(w, len(m.Taints))
-		}
-	case "namespace":
-		for n := range c.Namespaces {
-			w = utils.MaxInt(w, len(n))
-		}
-	}
-	return utils.MaxInt(w, min)
+// Report whether the LIM columns of a resource are shown
+func limitCol(res string) bool {
+	return res == string(corev1.ResourceCPU) || res == string(corev1.ResourceMemory)
+}
+
+// Report whether the USED column of a resource is shown
+func (c *Clustermetrics) usageCol(res string) bool {
+	return c.Usage && (res == string(corev1.ResourceCPU) || res == string(corev1.ResourceMemory))
 }
 
 // PrintNodeSummary Print utilization summary of each node in the cluster
+// The LIM columns are only shown for cpu and memory, the USED columns only when
+// usage was loaded from the metrics API.
 func (c *Clustermetrics) PrintNodeSummary() {
-	// Store the length of the longest value in each column
-	nw := c.maxW("name", 4)
-	sw := c.maxW("status", 6)
-	lw := c.maxW("label", 5)
-	tw := utils.MaxInt(c.TaintLen, 6)
-
-	// Use the '*' modifier for Printf to pad each column to the length of the longest value
-	fmt.Printf("%-*s  %-*s  %-*s  %-*s  %s  %s  %s\n", nw, "NODE", sw, "STATUS", lw, "LABEL", tw, "TAINTS", "CPU REQ", "MEM REQ", "PODS")
-
-	// Create a slice to hold the node names for sorting
-	var s []string
-	for n := range c.Nodes {
-		s = append(s, n)
+	c.nodeTable().print(c.Display)
+}
+
+// Build the node summary table, rows keyed by node name
+func (c *Clustermetrics) nodeTable() *table {
+	header := []string{"NODE", "STATUS", "LABEL", "TAINTS", "SCHEDULABLE"}
+	if c.alerting() {
+		header = append(header, "ALERT")
+	}
+	for _, res := range c.resourceNames() {
+		header = append(header, resTitle(res)+" REQ")
+		if limitCol(res) {
+			header = append(header, resTitle(res)+" LIM")
+		}
+		if c.usageCol(res) {
+			header = append(header, resTitle(res)+" USED")
+		}
 	}
-	// Sort node names alphabetically
-	sort.Strings(s)
-
-	// Loop through each node alphabetically
-	for _, name := range s {
-		if name != "" {
-			var n *Nodemetrics = c.Nodes[name]
-
-			// Sort the taints alphabetically (TODO: sort by master taints first?)
-			sort.Strings(n.Taints)
-
-			// If taints on the node are found, print the first with the rest of the node data
-			// Print each addtional taint on a new line with commas separating them
-			firstTaint := ""
-			if len(n.Taints) > 0 {
-				firstTaint = n.Taints[0]
+	header = append(header, "PODS")
+	if c.phased() {
+		header = append(header, "PHASES")
+	}
+	t := newTable(header...)
+	t.truncate("NODE", "LABEL", "TAINTS", "SCHEDULABLE", "PHASES")
+	alerts := c.nodeAlerts()
+
+	// Loop through each node in the selected order, alphabetically by default
+	for _, name := range c.SortedNodes() {
+		n := c.Nodes[name]
+
+		// Sort the taints alphabetically (TODO: sort by master taints first?)
+		sort.Strings(n.Taints)
+
+		// Print each taint on a line of its own in the same column, separated by commas
+		taints := cell{lines: []string{""}}
+		if len(n.Taints) > 0 {
+			taints.lines = append([]string{}, n.Taints...)
+			for i := 0; i < len(taints.lines)-1; i++ {
+				taints.lines[i] += ","
 			}
-			if len(n.Taints) > 1 {
-				firstTaint += ","
+		}
+		row := []cell{txt(name), txt(n.Status), txt(c.NodeLabel(n)), taints, txt(schedText(n))}
+		if c.alerting() {
+			row = append(row, alertCell(alerts[name]))
+		}
+		for _, res := range c.resourceNames() {
+			r := resStat(res, n.Cpu, n.Mem, n.Extended)
+			// Nodes without the resource, ie: no GPUs, show a dash rather than 0%
+			if extended(corev1.ResourceName(res)) && r.Avail == 0 && r.Req == 0 {
+				row = append(row, txt("-"))
+				continue
 			}
-			// Use the '*' modifier again to pad each column for consistent spacing
-			fmt.Printf("%-*v  %-*v  %-*s  %-*s  %-7v  %-7v  %v\n", nw, name, sw, n.Status, lw, n.Label, tw, firstTaint, utils.FmtPct(n.Cpu.Util), utils.FmtPct(n.Mem.Util), utils.FmtPct(n.Pods.Util))
-
-			// If there are multiple taints, print them on a line by themselves in the same column
-			for i := 1; i < len(n.Taints); i++ {
-				s := ""
-				t := n.Taints[i]
-				if (i + 1) < len(n.Taints) {
-					t += ","
-				}
-				fmt.Printf("%-*s  %-*s  %-*s  %-*s  %-7s  %-7s  %s\n", nw, s, sw, s, lw, s, tw, t, s, s, s)
+			row = append(row, c.utilCell(res, r.Util))
+			if limitCol(res) {
+				row = append(row, txt(utils.FmtPct(r.Overcommit)))
+			}
+			if c.usageCol(res) {
+				row = append(row, c.utilCell(res, utils.CalcPct(r.Avail, r.Used)))
 			}
 		}
+		row = append(row, c.utilCell(string(corev1.ResourcePods), n.Pods.Util))
+		if c.phased() {
+			row = append(row, txt(fmtPhases(n.Phases)))
+		}
+		t.addKeyed(name, row...)
 	}
+	return t
 }
 
 // PrintNamespaceSummary Print utilization summary of each namespace in the cluster
 func (c *Clustermetrics) PrintNamespaceSummary() {
-	// Store the length of the longest namespace for column padding
-	nsw := c.maxW("namespace", 9)
-	fmt.Printf("%-*s  %-7s  %-4s  %-9s  %-4s  %-4s  %s\n", nsw, "NAMESPACE", "CPU REQ", "UTIL", "MEM REQ", "UTIL", "PODS", "UTIL")
-
-	// Create a slice to hold the names for sorting
-	var s []string
-	for n := range c.Namespaces {
-		s = append(s, n)
+	c.namespaceTable().print(c.Display)
+}
+
+// Build the namespace summary table, rows keyed by namespace name
+func (c *Clustermetrics) namespaceTable() *table {
+	header := []string{"NAMESPACE"}
+	if c.alerting() {
+		header = append(header, "ALERT")
+	}
+	for _, res := range c.resourceNames() {
+		header = append(header, resTitle(res)+" REQ", "UTIL")
+		if limitCol(res) {
+			header = append(header, resTitle(res)+" LIM", "UTIL")
+		}
+		if c.usageCol(res) {
+			header = append(header, resTitle(res)+" USED")
+		}
 	}
-	// Sort namespaces alphabetically
-	sort.Strings(s)
-
-	// Print a formatted list of namespace resource data sorted by namespace alphabetically
-	for _, name := range s {
-		var n *Nsmetrics = c.Namespaces[name]
-		if name != "" {
-			fmt.Printf("%-*v  %-7v  %-4v  %-9s  %-4v  %-4v  %v\n", nsw, name, utils.FmtMilli(n.Cpu.Req), utils.FmtPct(n.Cpu.Util), utils.FmtMem(n.Mem.Req), utils.FmtPct(n.Mem.Util), (n.Pods.Inuse), utils.FmtPct(n.Pods.Util))
+	header = append(header, "PODS", "UTIL")
+	if c.phased() {
+		header = append(header, "PHASES")
+	}
+	t := newTable(header...)
+	t.truncate("NAMESPACE", "PHASES")
+	alerts := c.nsAlerts()
+
+	// Print a formatted list of namespace resource data in the selected order, alphabetically by default
+	for _, name := range c.SortedNamespaces() {
+		n := c.Namespaces[name]
+		row := []cell{txt(name)}
+		if c.alerting() {
+			row = append(row, alertCell(alerts[name]))
+		}
+		for _, res := range c.resourceNames() {
+			r := resStat(res, n.Cpu, n.Mem, n.Extended)
+			row = append(row, txt(fmtQuantity(res, r.Req)), c.utilCell(res, r.Util))
+			if limitCol(res) {
+				row = append(row, txt(fmtQuantity(res, r.Limit)), txt(utils.FmtPct(r.Overcommit)))
+			}
+			if c.usageCol(res) {
+				row = append(row, txt(fmtQuantity(res, r.Used)))
+			}
+		}
+		row = append(row, txt(fmt.Sprint(n.Pods.Inuse)), c.utilCell(string(corev1.ResourcePods), n.Pods.Util))
+		if c.phased() {
+			row = append(row, txt(fmtPhases(n.Phases)))
 		}
+		t.addKeyed(name, row...)
 	}
+	return t
 }
 
 // PrintClusterSummary Print utilization summary for the cluster
 func (c *Clustermetrics) PrintClusterSummary() {
-	memreq := utils.FmtMem(c.Mem.Req)
+	c.clusterTable().print(c.Display)
+}
+
+// Build the cluster summary table
+func (c *Clustermetrics) clusterTable() *table {
+	header := []string{"TOTAL RESOURCES", "REQUESTED", "AVAILABLE", "CAPACITY", "UTIL"}
+	if c.alerting() {
+		header = append(header, "ALERT")
+	}
+	t := newTable(header...)
+	alerts := c.clusterAlerts()
+	row := func(title string, req string, avail string, capacity string, util cell, res string) {
+		cells := []cell{txt(title), txt(req), txt(avail), txt(capacity), util}
+		if c.alerting() {
+			// The row names the resource, the cell only holds the level
+			a := alertCell(alerts[res])
+			if len(alerts[res]) > 0 {
+				a.lines = []string{levelName(a.level)[:4]}
+			}
+			cells = append(cells, a)
+		}
+		t.add(cells...)
+	}
 	memavail := utils.FmtMem(c.Mem.Avail)
 	memcap := utils.FmtMem(c.Mem.Cap)
-	cpureq := utils.FmtCPU(c.Cpu.Req)
 	cpuavail := utils.FmtCPU(c.Cpu.Avail)
 	cpucap := utils.FmtCPU(c.Cpu.Cap)
-	fmt.Printf("%-15s  %-10s %-10s %-10s %s\n", "TOTAL RESOURCES", "REQUESTED", "AVAILABLE", "CAPACITY", "UTIL")
-	fmt.Printf("%-15s  %-10v %-10v %-10v %s\n", "CPU", cpureq, cpuavail, cpucap, utils.FmtPct(c.Cpu.Util))
-	fmt.Printf("%-15s  %-10s %-10s %-10s %s\n", "MEMORY", memreq, memavail, memcap, utils.FmtPct(c.Mem.Util))
-	fmt.Printf("%-15v  %-10v %-10v %-10v %v\n", "PODS", c.Pods.Inuse, c.Pods.Avail, c.Pods.Cap, utils.FmtPct(c.Pods.Util))
+	pods := string(corev1.ResourcePods)
+
+	row("CPU", utils.FmtCPU(c.Cpu.Req), cpuavail, cpucap, c.utilCell("cpu", c.Cpu.Util), "cpu")
+	row("MEMORY", utils.FmtMem(c.Mem.Req), memavail, memcap, c.utilCell("memory", c.Mem.Util), "memory")
+	for _, res := range c.resourceNames() {
+		if extended(corev1.ResourceName(res)) {
+			r := resStat(res, c.Cpu, c.Mem, c.Extended)
+			row(strings.ToUpper(res), fmtQuantity(res, r.Req), fmtQuantity(res, r.Avail), fmtQuantity(res, r.Cap), c.utilCell(res, r.Util), res)
+		}
+	}
+	row("PODS", fmt.Sprint(c.Pods.Inuse), fmt.Sprint(c.Pods.Avail), fmt.Sprint(c.Pods.Cap), c.utilCell(pods, c.Pods.Util), pods)
+	// Limits of all pods, measured against the same available resources
+	row("CPU LIMITS", utils.FmtCPU(c.Cpu.Limit), cpuavail, cpucap, txt(utils.FmtPct(c.Cpu.Overcommit)), "")
+	row("MEMORY LIMITS", utils.FmtMem(c.Mem.Limit), memavail, memcap, txt(utils.FmtPct(c.Mem.Overcommit)), "")
+	// Demand of the pods waiting to be scheduled, measured against the same available resources
+	if len(c.Pending) > 0 {
+		pend := c.PendingTotals()
+		row("CPU PENDING", utils.FmtCPU(pend.Cpu), cpuavail, cpucap, txt(utils.FmtPct(utils.CalcPct(c.Cpu.Avail, pend.Cpu))), "")
+		row("MEMORY PENDING", utils.FmtMem(pend.Mem), memavail, memcap, txt(utils.FmtPct(utils.CalcPct(c.Mem.Avail, pend.Mem))), "")
+		row("PODS PENDING", fmt.Sprint(pend.Pods), fmt.Sprint(c.Pods.Avail), fmt.Sprint(c.Pods.Cap), txt(utils.FmtPct(utils.CalcPct(c.Pods.Avail, pend.Pods))), "")
+	}
+	// Actual consumption from the metrics API, measured against the same available resources
+	if c.Usage {
+		row("CPU USED", utils.FmtCPU(c.Cpu.Used), cpuavail, cpucap, c.utilCell("cpu", utils.CalcPct(c.Cpu.Avail, c.Cpu.Used)), "")
+		row("MEMORY USED", utils.FmtMem(c.Mem.Used), memavail, memcap, c.utilCell("memory", utils.CalcPct(c.Mem.Avail, c.Mem.Used)), "")
+	}
+	return t
 }
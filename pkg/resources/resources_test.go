@@ -0,0 +1,292 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestLoadNodes(t *testing.T) {
+	c := loadFake(t,
+		testNode("node1", "4", "8Gi", "110"),
+		testNode("node2", "2", "4Gi", "110"),
+		testPod("default", "web", "node1", "500m", "1Gi", true),
+	)
+	if len(c.Nodes) != 2 {
+		t.Fatalf("got %d nodes, want 2", len(c.Nodes))
+	}
+	n := c.Nodes["node1"]
+	if n.Label != "worker" {
+		t.Errorf("node1 label = %q, want worker", n.Label)
+	}
+	if n.Status != "Ready" {
+		t.Errorf("node1 status = %q, want Ready", n.Status)
+	}
+	if !n.Sched {
+		t.Error("node1 should be schedulable")
+	}
+	if n.Cpu.Avail != 4000 || n.Cpu.Req != 500 || n.Cpu.Util != 12 {
+		t.Errorf("node1 cpu = %+v, want avail 4000 req 500 util 12", n.Cpu)
+	}
+	if n.Mem.Req != 1<<30 || n.Mem.Util != 12 {
+		t.Errorf("node1 mem = %+v, want req 1Gi util 12", n.Mem)
+	}
+	if n.Pods.Inuse != 1 || n.Pods.Avail != 110 {
+		t.Errorf("node1 pods = %+v, want inuse 1 avail 110", n.Pods)
+	}
+	if c.Cpu.Avail != 6000 || c.Cpu.Cap != 6000 || c.Cpu.Req != 500 || c.Cpu.Util != 8 {
+		t.Errorf("cluster cpu = %+v, want avail 6000 cap 6000 req 500 util 8", c.Cpu)
+	}
+	if c.Pods.Avail != 220 || c.Pods.Inuse != 1 {
+		t.Errorf("cluster pods = %+v, want avail 220 inuse 1", c.Pods)
+	}
+}
+
+func TestLoadNamespaces(t *testing.T) {
+	c := loadFake(t,
+		testNode("node1", "4", "8Gi", "100"),
+		testPod("default", "web", "node1", "1", "2Gi", true),
+		testPod("default", "db", "node1", "1", "2Gi", true),
+		testPod("monitoring", "prom", "node1", "500m", "1Gi", true),
+	)
+	ns := c.Namespaces["default"]
+	if ns == nil {
+		t.Fatal("namespace default not collected")
+	}
+	if ns.Cpu.Req != 2000 || ns.Cpu.Util != 50 {
+		t.Errorf("default cpu = %+v, want req 2000 util 50", ns.Cpu)
+	}
+	if ns.Mem.Req != 4<<30 || ns.Mem.Util != 50 {
+		t.Errorf("default mem = %+v, want req 4Gi util 50", ns.Mem)
+	}
+	if ns.Pods.Inuse != 2 || ns.Pods.Util != 2 {
+		t.Errorf("default pods = %+v, want inuse 2 util 2", ns.Pods)
+	}
+	if c.Namespaces["monitoring"].Cpu.Req != 500 {
+		t.Errorf("monitoring cpu req = %d, want 500", c.Namespaces["monitoring"].Cpu.Req)
+	}
+}
+
+func TestLoadCountsScheduledPods(t *testing.T) {
+	pulling := testPod("default", "pulling", "node1", "1", "1Gi", false)
+	pulling.Status.Phase = corev1.PodPending
+	done := testPod("default", "done", "node1", "1", "1Gi", false)
+	done.Status.Phase = corev1.PodSucceeded
+	failed := testPod("default", "failed", "node1", "1", "1Gi", false)
+	failed.Status.Phase = corev1.PodFailed
+	c := loadFake(t,
+		testNode("node1", "4", "8Gi", "110"),
+		testPod("default", "ready", "node1", "1", "1Gi", true),
+		testPod("default", "crashing", "node1", "1", "1Gi", false),
+		pulling, done, failed,
+	)
+	// Terminated pods release their resources, everything else bound to the node counts
+	n := c.Nodes["node1"]
+	if n.Cpu.Req != 3000 || n.Pods.Inuse != 3 {
+		t.Errorf("node1 cpu req = %d pods = %d, want 3000 and 3", n.Cpu.Req, n.Pods.Inuse)
+	}
+	if p := n.Phases["Running"]; p == nil || p.Pods != 2 || p.Cpu != 2000 || p.Mem != 2<<30 {
+		t.Errorf("node1 running phase = %+v, want 2 pods 2000m 2Gi", p)
+	}
+	if p := c.Namespaces["default"].Phases["Pending"]; p == nil || p.Pods != 1 || p.Cpu != 1000 {
+		t.Errorf("default pending phase = %+v, want 1 pod 1000m", p)
+	}
+	if p := c.Phases["Pending"]; p == nil || p.Pods != 1 || len(c.Phases) != 2 {
+		t.Errorf("cluster phases = %+v, want Running and Pending", c.Phases)
+	}
+	if got := fmtPhases(n.Phases); got != "Pending 1000m/1 GiB" {
+		t.Errorf("fmtPhases = %q", got)
+	}
+}
+
+func TestLoadReadyOnly(t *testing.T) {
+	c := NewCluster()
+	c.Filter.ReadyOnly = true
+	err := c.Load(fake.NewSimpleClientset(
+		testNode("node1", "4", "8Gi", "110"),
+		testPod("default", "ready", "node1", "1", "1Gi", true),
+		testPod("default", "crashing", "node1", "1", "1Gi", false),
+	))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := c.Nodes["node1"].Cpu.Req; got != 1000 {
+		t.Errorf("node1 cpu req = %d, want 1000", got)
+	}
+	if got := c.Nodes["node1"].Pods.Inuse; got != 1 {
+		t.Errorf("node1 pods inuse = %d, want 1", got)
+	}
+}
+
+func TestLoadUnschedulableNode(t *testing.T) {
+	taint := corev1.Taint{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule}
+	c := loadFake(t,
+		testNode("master", "4", "8Gi", "110", taint),
+		testNode("worker", "4", "8Gi", "110"),
+		testPod("kube-system", "etcd", "master", "1", "1Gi", true),
+	)
+	m := c.Nodes["master"]
+	if m.Sched {
+		t.Error("master should not be schedulable")
+	}
+	if len(m.Taints) != 1 || m.Taints[0] != "master:NoSchedule" {
+		t.Errorf("master taints = %v, want [master:NoSchedule]", m.Taints)
+	}
+	// Only the worker's allocatable counts, plus the requests already placed on the master
+	if c.Cpu.Avail != 5000 {
+		t.Errorf("cluster cpu avail = %d, want 5000", c.Cpu.Avail)
+	}
+	if c.Pods.Avail != 111 {
+		t.Errorf("cluster pods avail = %d, want 111", c.Pods.Avail)
+	}
+}
+
+func TestLoadSchedulability(t *testing.T) {
+	cordoned := testNode("cordoned", "4", "8Gi", "110")
+	cordoned.Spec.Unschedulable = true
+	notready := testNode("notready", "4", "8Gi", "110", corev1.Taint{Key: corev1.TaintNodeNotReady, Effect: corev1.TaintEffectNoExecute})
+	notready.Status.Conditions[0].Status = corev1.ConditionFalse
+	dedicated := testNode("dedicated", "4", "8Gi", "110", corev1.Taint{Key: "example.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule})
+	preferred := testNode("preferred", "4", "8Gi", "110", corev1.Taint{Key: "example.com/spot", Effect: corev1.TaintEffectPreferNoSchedule})
+	c := loadFake(t, cordoned, notready, dedicated, preferred,
+		testPod("default", "web", "cordoned", "1", "1Gi", true),
+	)
+
+	tests := []struct {
+		node    string
+		sched   bool
+		reasons []string
+		status  string
+	}{
+		{"cordoned", false, []string{ReasonCordoned}, "Ready"},
+		{"notready", false, []string{ReasonNotReady}, "NotReady"},
+		{"dedicated", false, []string{"example.com/gpu:NoSchedule"}, "Ready"},
+		{"preferred", true, nil, "Ready"},
+	}
+	for _, tt := range tests {
+		n := c.Nodes[tt.node]
+		if n.Sched != tt.sched || !reflect.DeepEqual(n.SchedReasons, tt.reasons) || n.Status != tt.status {
+			t.Errorf("%s: sched = %v %v, status %q, want %v %v, status %q", tt.node, n.Sched, n.SchedReasons, n.Status, tt.sched, tt.reasons, tt.status)
+		}
+	}
+	if got := schedText(c.Nodes["cordoned"]); got != "no (cordoned)" {
+		t.Errorf("schedText = %q, want no (cordoned)", got)
+	}
+	// Only the preferred node's allocatable counts, plus the requests already placed on the cordoned node
+	if c.Cpu.Avail != 5000 || c.Pods.Avail != 111 {
+		t.Errorf("cluster avail = %d cpu %d pods, want 5000 cpu 111 pods", c.Cpu.Avail, c.Pods.Avail)
+	}
+}
+
+func TestLoadIgnoresPodsOnUnknownNodes(t *testing.T) {
+	c := loadFake(t,
+		testNode("node1", "4", "8Gi", "110"),
+		testPod("default", "web", "node1", "1", "1Gi", true),
+		testPod("default", "gone", "deleted", "1", "1Gi", true),
+	)
+	if _, ok := c.Nodes["deleted"]; ok {
+		t.Error("pod on an unknown node created a node entry")
+	}
+	if c.Cpu.Req != 1000 {
+		t.Errorf("cluster cpu req = %d, want 1000", c.Cpu.Req)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	c := NewCluster()
+	if err := c.Load(fake.NewSimpleClientset()); err == nil {
+		t.Error("expected an error with no nodes")
+	}
+
+	cs := fake.NewSimpleClientset(testNode("node1", "4", "8Gi", "110"))
+	cs.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	c = NewCluster()
+	if err := c.Load(cs); err == nil {
+		t.Error("expected an error when listing pods fails")
+	}
+}
+
+func TestUpdateNode(t *testing.T) {
+	c := NewCluster()
+	n := NewNodemetrics()
+	n.Label = "worker"
+	n.Status = "Ready"
+	n.Sched = true
+	n.Cpu.Avail = 4000
+	n.Pods.Avail = 110
+	c.UpdateNode("node1", n)
+
+	// Pod data is added on top of the node data
+	p := NewNodemetrics()
+	p.Cpu.Req = 250
+	p.Cpu.Limit = 500
+	p.Mem.Req = 1024
+	p.Pods.Inuse = 1
+	c.UpdateNode("node1", p)
+	c.UpdateNode("node1", p)
+
+	got := c.Nodes["node1"]
+	if got.Cpu.Req != 500 || got.Cpu.Limit != 1000 || got.Mem.Req != 2048 || got.Pods.Inuse != 2 {
+		t.Errorf("accumulated node = %+v", got)
+	}
+	if got.Label != "worker" || got.Status != "Ready" || got.Cpu.Avail != 4000 || got.Pods.Avail != 110 {
+		t.Errorf("node data overwritten by pod data: %+v", got)
+	}
+
+	// A later status update replaces the status and schedulability
+	s := NewNodemetrics()
+	s.Status = "Ready,MemoryPressure"
+	s.Sched = false
+	c.UpdateNode("node1", s)
+	if got.Status != "Ready,MemoryPressure" || got.Sched {
+		t.Errorf("status update not applied: %+v", got)
+	}
+}
+
+func TestUpdateNamespace(t *testing.T) {
+	c := NewCluster()
+	for i := 0; i < 3; i++ {
+		n := NewNsmetrics()
+		n.Cpu.Req = 100
+		n.Mem.Limit = 1024
+		n.Pods.Inuse = 1
+		c.UpdateNamespace("default", n)
+	}
+	u := NewNsmetrics()
+	u.Cpu.Util = 42
+	c.UpdateNamespace("default", u)
+
+	got := c.Namespaces["default"]
+	if got.Cpu.Req != 300 || got.Mem.Limit != 3072 || got.Pods.Inuse != 3 {
+		t.Errorf("accumulated namespace = %+v", got)
+	}
+	if got.Cpu.Util != 42 {
+		t.Errorf("namespace cpu util = %d, want 42", got.Cpu.Util)
+	}
+}
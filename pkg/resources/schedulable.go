@@ -0,0 +1,110 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reasons a node does not accept new pods, besides its taints
+const (
+	ReasonCordoned = "cordoned"
+	ReasonNotReady = "NotReady"
+)
+
+// Taints the node controllers add to cordoned and not ready nodes, reported by their cause instead
+var causedTaints = map[string]string{
+	corev1.TaintNodeUnschedulable: ReasonCordoned,
+	corev1.TaintNodeNotReady:      ReasonNotReady,
+	corev1.TaintNodeUnreachable:   ReasonNotReady,
+}
+
+// Shorten the well known taint prefixes, ie: node-role.kubernetes.io/master becomes master
+func taintKey(key string) string {
+	prefix, name, ok := strings.Cut(key, "/")
+	if ok && (prefix == "node-role.kubernetes.io" || prefix == "node.kubernetes.io") {
+		return name
+	}
+	return key
+}
+
+// Format a taint in full like kubectl taint, ie: example.com/gpu=true:NoSchedule
+func fullTaint(t corev1.Taint) string {
+	if len(t.Value) == 0 {
+		return t.Key + ":" + string(t.Effect)
+	}
+	return t.Key + "=" + t.Value + ":" + string(t.Effect)
+}
+
+// Report whether a node has a Ready condition which is not True
+// Nodes which have not posted one yet carry the not-ready taint instead.
+func notReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status != corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// Return why new pods can not be scheduled on a node, nothing when they can
+// A node is unschedulable when it is cordoned (spec.unschedulable), not ready,
+// or has any NoSchedule or NoExecute taint whatever its key.
+func unschedulable(node *corev1.Node) []string {
+	var reasons []string
+	add := func(reason string) {
+		for _, r := range reasons {
+			if r == reason {
+				return
+			}
+		}
+		reasons = append(reasons, reason)
+	}
+	if node.Spec.Unschedulable {
+		add(ReasonCordoned)
+	}
+	if notReady(node) {
+		add(ReasonNotReady)
+	}
+	for _, t := range node.Spec.Taints {
+		if t.Effect != corev1.TaintEffectNoSchedule && t.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		if reason, ok := causedTaints[t.Key]; ok {
+			add(reason)
+			continue
+		}
+		add(taintKey(t.Key) + ":" + string(t.Effect))
+	}
+	return reasons
+}
+
+// Format the SCHEDULABLE column of a node, ie: no (cordoned)
+func schedText(n *Nodemetrics) string {
+	if n.Sched {
+		return "yes"
+	}
+	if len(n.SchedReasons) == 0 {
+		return "no"
+	}
+	return "no (" + strings.Join(n.SchedReasons, ", ") + ")"
+}
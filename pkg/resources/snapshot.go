@@ -0,0 +1,160 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// Snapshot Raw node and pod objects a Clustermetrics object is built from
+// Saved as a v1 List so it reads the same as "kubectl get nodes,pods -A -o json".
+type Snapshot struct {
+	Nodes []corev1.Node
+	Pods  []corev1.Pod
+}
+
+// TakeSnapshot List the nodes and pods matching the filter
+func TakeSnapshot(cs kubernetes.Interface, f Filter) (*Snapshot, error) {
+	// Catch a malformed filter before sending it to the API server
+	if _, _, err := f.selectors(); err != nil {
+		return nil, err
+	}
+	// Retrieve a list of nodes from the cluster as type nodelist
+	mynodes, err := cs.CoreV1().Nodes().List(context.TODO(), f.NodeListOptions())
+	if err != nil {
+		return nil, fmt.Errorf("could not list nodes: %w", err)
+	}
+	// Retrieve a list of pods from the cluster as type podlist
+	mypods, err := cs.CoreV1().Pods(f.PodNamespace()).List(context.TODO(), f.PodListOptions())
+	if err != nil {
+		return nil, fmt.Errorf("could not list pods: %w", err)
+	}
+	return &Snapshot{Nodes: mynodes.Items, Pods: mypods.Items}, nil
+}
+
+// LoadSnapshot Collect utilization data from a snapshot into the Clustermetrics object
+func (c *Clustermetrics) LoadSnapshot(s *Snapshot) error {
+	return c.LoadItems(s.Nodes, s.Pods)
+}
+
+// The envelope of a v1 List, items are decoded by kind
+type snapshotList struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Items      []json.RawMessage `json:"items"`
+}
+
+// Write Write the snapshot as an indented JSON v1 List
+func (s *Snapshot) Write(w io.Writer) error {
+	l := snapshotList{APIVersion: "v1", Kind: "List", Items: []json.RawMessage{}}
+	for _, n := range s.Nodes {
+		n.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Node"}
+		// Field ownership is of no use for capacity analysis and often the bulk of the object
+		n.ManagedFields = nil
+		b, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		l.Items = append(l.Items, b)
+	}
+	for _, p := range s.Pods {
+		p.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+		p.ManagedFields = nil
+		b, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		l.Items = append(l.Items, b)
+	}
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// ReadSnapshot Read a snapshot saved by Write or the JSON or YAML output of
+// "kubectl get nodes,pods -A -o json". A NodeList, PodList or single object
+// is accepted as well; other kinds of objects are ignored.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// YAML is a superset of JSON, so converting handles both
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse snapshot: %w", err)
+	}
+	var l snapshotList
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("could not parse snapshot: %w", err)
+	}
+	s := &Snapshot{}
+	if !strings.HasSuffix(l.Kind, "List") {
+		// A single object
+		return s, s.add(l.Kind, data)
+	}
+	// Items of a typed list (ie: the raw API NodeList) carry no kind of their own
+	itemKind := strings.TrimSuffix(l.Kind, "List")
+	for _, item := range l.Items {
+		var t metav1.TypeMeta
+		if err := json.Unmarshal(item, &t); err != nil {
+			return nil, fmt.Errorf("could not parse snapshot item: %w", err)
+		}
+		kind := t.Kind
+		if len(kind) == 0 {
+			kind = itemKind
+		}
+		if err := s.add(kind, item); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Decode an object and add it to the snapshot if it is a node or pod
+func (s *Snapshot) add(kind string, data []byte) error {
+	switch kind {
+	case "Node":
+		var n corev1.Node
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("could not parse node: %w", err)
+		}
+		s.Nodes = append(s.Nodes, n)
+	case "Pod":
+		var p corev1.Pod
+		if err := json.Unmarshal(data, &p); err != nil {
+			return fmt.Errorf("could not parse pod: %w", err)
+		}
+		s.Pods = append(s.Pods, p)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		testNode("node1", "4", "8Gi", "110"),
+		testPod("default", "web", "node1", "1", "1Gi", true),
+		testPod("kube-system", "dns", "node1", "100m", "128Mi", true),
+	)
+	s, err := TakeSnapshot(cs, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := s.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"kind": "List"`) {
+		t.Error("snapshot is not written as a v1 List")
+	}
+
+	r, err := ReadSnapshot(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Nodes) != 1 || len(r.Pods) != 2 {
+		t.Fatalf("read %d nodes and %d pods, want 1 and 2", len(r.Nodes), len(r.Pods))
+	}
+
+	// The snapshot must produce the same numbers as loading from the cluster
+	live := loadFake(t, testNode("node1", "4", "8Gi", "110"),
+		testPod("default", "web", "node1", "1", "1Gi", true),
+		testPod("kube-system", "dns", "node1", "100m", "128Mi", true))
+	c := NewCluster()
+	if err := c.LoadSnapshot(r); err != nil {
+		t.Fatal(err)
+	}
+	if c.Cpu != live.Cpu || c.Mem != live.Mem || c.Pods != live.Pods {
+		t.Errorf("snapshot totals %+v %+v %+v differ from live %+v %+v %+v", c.Cpu, c.Mem, c.Pods, live.Cpu, live.Mem, live.Pods)
+	}
+}
+
+// Trimmed output of "kubectl get nodes,pods -A -o yaml" with an unrelated object mixed in
+const kubectlYAML = `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Node
+  metadata:
+    name: node1
+  status:
+    allocatable: {cpu: "2", memory: 4Gi, pods: "110"}
+    capacity: {cpu: "2", memory: 4Gi, pods: "110"}
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: kubernetes
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: web
+    namespace: default
+  spec:
+    nodeName: node1
+    containers:
+    - name: app
+      resources:
+        requests: {cpu: 500m, memory: 1Gi}
+  status:
+    containerStatuses:
+    - name: app
+      ready: true
+`
+
+func TestReadSnapshotKubectl(t *testing.T) {
+	s, err := ReadSnapshot(strings.NewReader(kubectlYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Nodes) != 1 || len(s.Pods) != 1 {
+		t.Fatalf("read %d nodes and %d pods, want 1 and 1", len(s.Nodes), len(s.Pods))
+	}
+	c := NewCluster()
+	if err := c.LoadSnapshot(s); err != nil {
+		t.Fatal(err)
+	}
+	if c.Cpu.Util != 25 || c.Mem.Util != 25 {
+		t.Errorf("cluster util = %d cpu %d mem, want 25 and 25", c.Cpu.Util, c.Mem.Util)
+	}
+}
+
+func TestReadSnapshotTypedList(t *testing.T) {
+	// The raw API NodeList leaves out the kind of each item
+	s, err := ReadSnapshot(strings.NewReader(`{"kind":"NodeList","apiVersion":"v1","items":[{"metadata":{"name":"node1"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Nodes) != 1 || s.Nodes[0].Name != "node1" {
+		t.Errorf("nodes = %+v, want node1", s.Nodes)
+	}
+	if _, err := ReadSnapshot(strings.NewReader("{not json")); err == nil {
+		t.Error("expected an error for a malformed snapshot")
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortKeys Keys accepted by Order.By
+var SortKeys = []string{"cpu", "mem", "pods", "cpu-limit", "mem-limit", "name", "role"}
+
+// Order Sort order and length of the node, namespace and pod tables
+// The zero value keeps each table's default order and shows every row. Resource
+// keys rank the fullest first: nodes by the share of their allocatable resources,
+// namespaces and pods by the amount requested. Name and role sort alphabetically.
+type Order struct {
+	By      string // One of SortKeys
+	Reverse bool   // Reverse the order
+	Top     int    // Show only the first rows, 0 for all
+}
+
+// Validate Return an error if the order cannot be applied
+func (o Order) Validate() error {
+	if len(o.By) > 0 && !contains(SortKeys, o.By) {
+		return fmt.Errorf("unknown sort key %q, use one of %s", o.By, strings.Join(SortKeys, ", "))
+	}
+	if o.Top < 0 {
+		return fmt.Errorf("invalid --top %d, must not be negative", o.Top)
+	}
+	return nil
+}
+
+// Return v as a fraction of avail, 0 when nothing is available
+func fraction(v int64, avail int64) float64 {
+	if avail <= 0 {
+		return 0
+	}
+	return float64(v) / float64(avail)
+}
+
+// Return the value a set of figures is ranked by for a resource key
+// With shares set values are relative to avail, otherwise absolute.
+func (o Order) value(cpu Restat, mem Restat, pods Imetric, shares bool) float64 {
+	var v, avail int64
+	switch o.By {
+	case "cpu":
+		v, avail = cpu.Req, cpu.Avail
+	case "mem":
+		v, avail = mem.Req, mem.Avail
+	case "pods":
+		v, avail = pods.Inuse, pods.Avail
+	case "cpu-limit":
+		v, avail = cpu.Limit, cpu.Avail
+	case "mem-limit":
+		v, avail = mem.Limit, mem.Avail
+	}
+	if shares {
+		return fraction(v, avail)
+	}
+	return float64(v)
+}
+
+// Sort names alphabetically, then by value (largest first) or label for the other keys
+func (o Order) sort(s []string, value func(string) float64, label func(string) string) []string {
+	sort.Strings(s)
+	switch o.By {
+	case "", "name":
+	case "role":
+		sort.SliceStable(s, func(i, j int) bool { return label(s[i]) < label(s[j]) })
+	default:
+		sort.SliceStable(s, func(i, j int) bool { return value(s[i]) > value(s[j]) })
+	}
+	return arrange(o, s)
+}
+
+// Apply the Reverse and Top settings of an order to a sorted slice
+func arrange[T any](o Order, s []T) []T {
+	if o.Reverse {
+		for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+			s[i], s[j] = s[j], s[i]
+		}
+	}
+	if o.Top > 0 && len(s) > o.Top {
+		s = s[:o.Top]
+	}
+	return s
+}
+
+// SortedNodes Return the node names in the order and number selected by c.Order
+func (c *Clustermetrics) SortedNodes() []string {
+	var s []string
+	for n := range c.Nodes {
+		if n != "" {
+			s = append(s, n)
+		}
+	}
+	return c.Order.sort(s, func(name string) float64 {
+		n := c.Nodes[name]
+		return c.Order.value(n.Cpu, n.Mem, n.Pods, true)
+	}, func(name string) string {
+		return c.NodeLabel(c.Nodes[name])
+	})
+}
+
+// SortedNamespaces Return the namespace names in the order and number selected by c.Order
+// Namespaces have no role, sorting by role sorts them by name.
+func (c *Clustermetrics) SortedNamespaces() []string {
+	var s []string
+	for n := range c.Namespaces {
+		if n != "" {
+			s = append(s, n)
+		}
+	}
+	return c.Order.sort(s, func(name string) float64 {
+		n := c.Namespaces[name]
+		return c.Order.value(n.Cpu, n.Mem, n.Pods, false)
+	}, func(name string) string {
+		return name
+	})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortedNodes(t *testing.T) {
+	c := loadFake(t,
+		testNode("a", "4", "8Gi", "110"),
+		testNode("b", "8", "8Gi", "110"),
+		testNode("c", "4", "16Gi", "110"),
+		testPod("web", "p1", "a", "1", "1Gi", true),
+		testPod("web", "p2", "b", "3", "1Gi", true),
+		testPod("db", "p3", "c", "500m", "6Gi", true),
+		testPod("db", "p4", "c", "500m", "1Gi", true),
+	)
+	c.Nodes["b"].Label = "infra"
+	tests := []struct {
+		order Order
+		want  []string
+	}{
+		{Order{}, []string{"a", "b", "c"}},
+		{Order{By: "cpu"}, []string{"b", "a", "c"}},
+		{Order{By: "mem"}, []string{"c", "a", "b"}},
+		{Order{By: "pods", Top: 1}, []string{"c"}},
+		{Order{By: "cpu", Reverse: true}, []string{"c", "a", "b"}},
+		{Order{By: "role"}, []string{"b", "a", "c"}},
+		{Order{By: "name", Reverse: true, Top: 2}, []string{"c", "b"}},
+	}
+	for _, tt := range tests {
+		c.Order = tt.order
+		if got := c.SortedNodes(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SortedNodes(%+v) = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestSortedNamespaces(t *testing.T) {
+	c := loadFake(t,
+		testNode("a", "16", "64Gi", "110"),
+		testPod("web", "p1", "a", "4", "1Gi", true),
+		testPod("db", "p2", "a", "1", "8Gi", true),
+		testPod("ops", "p3", "a", "2", "2Gi", true),
+	)
+	c.Order = Order{By: "mem", Top: 2}
+	if got := c.SortedNamespaces(); !reflect.DeepEqual(got, []string{"db", "ops"}) {
+		t.Errorf("SortedNamespaces by mem = %v, want [db ops]", got)
+	}
+	c.Order = Order{By: "cpu"}
+	if got := c.SortedNamespaces(); !reflect.DeepEqual(got, []string{"web", "ops", "db"}) {
+		t.Errorf("SortedNamespaces by cpu = %v, want [web ops db]", got)
+	}
+	c.Order = Order{By: "name", Top: 2}
+	if r := c.Report(true, true, false, false); len(r.Namespaces) != 2 || r.Namespaces[0].Name != "db" {
+		t.Errorf("report namespaces = %+v, want db and ops", r.Namespaces)
+	}
+}
+
+func TestOrderValidate(t *testing.T) {
+	for _, o := range []Order{{}, {By: "mem-limit", Reverse: true, Top: 5}} {
+		if err := o.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", o, err)
+		}
+	}
+	for _, o := range []Order{{By: "memory"}, {Top: -1}} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate(%+v) accepted an invalid order", o)
+		}
+	}
+}
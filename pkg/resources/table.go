@@ -0,0 +1,268 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+)
+
+// Display Settings of the table renderer
+// The zero value prints plain tables of any width, as suits pipes and files.
+type Display struct {
+	Color bool // Color utilization cells green, yellow or red
+	Bars  bool // Draw a bar next to utilization cells
+	Width int  // Terminal width to fit the tables into, 0 for no limit
+}
+
+// Utilization at which cells turn yellow and red when no --warn or --crit threshold is set
+const (
+	defaultWarn = 80
+	defaultCrit = 90
+)
+
+// Width of the utilization bars and the narrowest a truncated column gets
+const (
+	barWidth = 10
+	minWidth = 8
+)
+
+// ANSI escape sequences of the colors of each alert level
+var levelColors = map[int]string{
+	AlertOK:       "\033[32m",
+	AlertWarning:  "\033[33m",
+	AlertCritical: "\033[31m",
+}
+
+const colorReset = "\033[0m"
+
+// A table cell holding one or more lines of text
+// Colored cells are drawn in the color of their level, utilization cells also
+// hold the percentage their bar is drawn for.
+type cell struct {
+	lines []string
+	color bool
+	util  bool
+	pct   int64
+	level int
+}
+
+// Return a plain cell
+func txt(s string) cell {
+	return cell{lines: []string{s}}
+}
+
+// Return a utilization cell of a resource, leveled against its --warn and --crit thresholds
+func (c *Clustermetrics) utilCell(res string, pct int64) cell {
+	return levelCell(res, pct, c.Warn, c.Crit)
+}
+
+// Return a utilization cell of a resource, leveled against the given thresholds
+func levelCell(res string, pct int64, warnAt Thresholds, critAt Thresholds) cell {
+	warn, crit := int64(defaultWarn), int64(defaultCrit)
+	if t, ok := warnAt[res]; ok {
+		warn = t
+	}
+	if t, ok := critAt[res]; ok {
+		crit = t
+	}
+	level := AlertOK
+	switch {
+	case pct >= crit:
+		level = AlertCritical
+	case pct >= warn:
+		level = AlertWarning
+	}
+	return cell{lines: []string{utils.FmtPct(pct)}, color: true, util: true, pct: pct, level: level}
+}
+
+// Return an ALERT cell, colored at the highest level of the alerts
+func alertCell(alerts []Alert) cell {
+	return cell{lines: []string{alertText(alerts)}, color: true, level: AlertStatus(alerts)}
+}
+
+// Return a bar of barWidth characters filled to a percentage, ie: █████░░░░░
+func bar(pct int64) string {
+	n := int((utils.MaxInt(0, int(pct)) + 5) / 10)
+	if n > barWidth {
+		n = barWidth
+	}
+	return strings.Repeat("█", n) + strings.Repeat("░", barWidth-n)
+}
+
+// Return line i of a cell as displayed, empty past its last line
+func (cl cell) line(i int, d Display) string {
+	if i >= len(cl.lines) {
+		return ""
+	}
+	if cl.util && d.Bars {
+		return Pad(cl.lines[i], 4) + " " + bar(cl.pct)
+	}
+	return cl.lines[i]
+}
+
+// Return the number of characters of a string as displayed
+func width(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// Pad Pad a string with spaces to w characters as displayed
+// Unlike fmt's %-*s, which counts bytes, bars and ellipses count as one character.
+func Pad(s string, w int) string {
+	if n := width(s); n < w {
+		return s + strings.Repeat(" ", w-n)
+	}
+	return s
+}
+
+// Cut a string to w characters, marking the cut with an ellipsis
+func clip(s string, w int) string {
+	if width(s) <= w {
+		return s
+	}
+	if w < 1 {
+		return ""
+	}
+	r := []rune(s)
+	return string(r[:w-1]) + "…"
+}
+
+// A table of cells, the first row holding the headers
+// Rows may be keyed by the object they show, ie: the node name.
+type table struct {
+	rows  [][]cell
+	keys  []string
+	trunc map[int]bool
+}
+
+// Create a table with the given headers
+func newTable(header ...string) *table {
+	var row []cell
+	for _, h := range header {
+		row = append(row, txt(h))
+	}
+	return &table{rows: [][]cell{row}, keys: []string{""}, trunc: map[int]bool{}}
+}
+
+// Append a row to the table
+func (t *table) add(cells ...cell) {
+	t.addKeyed("", cells...)
+}
+
+// Append a row showing the object with the given key
+func (t *table) addKeyed(key string, cells ...cell) {
+	t.rows = append(t.rows, cells)
+	t.keys = append(t.keys, key)
+}
+
+// Allow the columns with the given headers to be truncated to fit the terminal
+// Names, labels and messages give way so the figures stay readable.
+func (t *table) truncate(headers ...string) {
+	for i, h := range t.rows[0] {
+		for _, name := range headers {
+			if h.lines[0] == name {
+				t.trunc[i] = true
+			}
+		}
+	}
+}
+
+// Print the table to stdout
+func (t *table) print(d Display) {
+	t.write(os.Stdout, d)
+}
+
+// Write the table
+func (t *table) write(w io.Writer, d Display) {
+	for _, lines := range t.render(d) {
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// Render the lines of each row, padding each column to its longest value
+// Tables wider than d.Width are narrowed by truncating the widest truncatable
+// column first. The last column is not padded.
+func (t *table) render(d Display) [][]string {
+	var widths []int
+	for _, row := range t.rows {
+		for i, cl := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			for l := range cl.lines {
+				widths[i] = utils.MaxInt(widths[i], width(cl.line(l, d)))
+			}
+		}
+	}
+	if d.Width > 0 {
+		total := func() int {
+			n := 2 * (len(widths) - 1)
+			for _, cw := range widths {
+				n += cw
+			}
+			return n
+		}
+		for total() > d.Width {
+			widest := -1
+			for i, cw := range widths {
+				if t.trunc[i] && cw > minWidth && (widest < 0 || cw > widths[widest]) {
+					widest = i
+				}
+			}
+			if widest < 0 {
+				break
+			}
+			widths[widest] = utils.MaxInt(minWidth, widths[widest]-(total()-d.Width))
+		}
+	}
+
+	var out [][]string
+	for _, row := range t.rows {
+		lines := 1
+		for _, cl := range row {
+			lines = utils.MaxInt(lines, len(cl.lines))
+		}
+		var rendered []string
+		for l := 0; l < lines; l++ {
+			var b strings.Builder
+			for i, cl := range row {
+				s := clip(cl.line(l, d), widths[i])
+				pad := widths[i] - width(s)
+				if cl.color && d.Color && len(s) > 0 {
+					s = levelColors[cl.level] + s + colorReset
+				}
+				b.WriteString(s)
+				if i < len(row)-1 {
+					b.WriteString(strings.Repeat(" ", pad+2))
+				}
+			}
+			rendered = append(rendered, strings.TrimRight(b.String(), " "))
+		}
+		out = append(out, rendered)
+	}
+	return out
+}
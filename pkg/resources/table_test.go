@@ -0,0 +1,76 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTableLayout(t *testing.T) {
+	tb := newTable("NODE", "TAINTS", "CPU REQ")
+	tb.truncate("NODE", "TAINTS")
+	tb.add(txt("a-very-long-node-name.example.com"), cell{lines: []string{"master:NoSchedule,", "infra:NoSchedule"}}, txt("50%"))
+	tb.add(txt("node-2"), txt(""), txt("7%"))
+
+	var b bytes.Buffer
+	tb.write(&b, Display{})
+	want := "NODE                               TAINTS              CPU REQ\n" +
+		"a-very-long-node-name.example.com  master:NoSchedule,  50%\n" +
+		"                                   infra:NoSchedule\n" +
+		"node-2                                                 7%\n"
+	if b.String() != want {
+		t.Errorf("table =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	// Narrow terminals truncate the widest truncatable column first
+	b.Reset()
+	tb.write(&b, Display{Width: 40})
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		if width(line) > 40 {
+			t.Errorf("line %q is wider than 40", line)
+		}
+	}
+	if !strings.HasPrefix(b.String(), "NODE         TAINTS") || !strings.Contains(b.String(), "a-very-lon…  master") {
+		t.Errorf("truncated table =\n%s", b.String())
+	}
+}
+
+func TestTableColors(t *testing.T) {
+	c := NewCluster()
+	c.Crit = Thresholds{"memory": 70}
+	tb := newTable("CPU", "MEM")
+	tb.add(c.utilCell("cpu", 85), c.utilCell("memory", 75))
+
+	var b bytes.Buffer
+	tb.write(&b, Display{Color: true, Bars: true})
+	want := "CPU              MEM\n" +
+		"\033[33m85%  █████████░\033[0m  \033[31m75%  ████████░░\033[0m\n"
+	if b.String() != want {
+		t.Errorf("colored table = %q, want %q", b.String(), want)
+	}
+	if got := bar(130); got != strings.Repeat("█", barWidth) {
+		t.Errorf("bar(130) = %q", got)
+	}
+	if got := Pad("75%  ██░…", 12); got != "75%  ██░…   " {
+		t.Errorf("Pad = %q, want padding to 12 characters", got)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Template A user supplied Go template or JSONPath template rendering a structured report
+// Templates see the report as its JSON document, so field names match the
+// JSON output, ie: {{range .nodes}}{{.name}} {{.cpu.util}}{{"\n"}}{{end}}
+type Template struct {
+	execute func(w io.Writer, data interface{}) error
+}
+
+// Functions available to Go templates for formatting report values
+var templateFuncs = template.FuncMap{
+	"cpu":   utils.FmtCPU,
+	"milli": utils.FmtMilli,
+	"mem":   utils.FmtMem,
+	"pct":   utils.FmtPct,
+}
+
+// NewGoTemplate Parse a Go template
+// Besides the built in functions, cpu, milli, mem and pct format millicores,
+// bytes and percentages as the tables do, ie: {{.memory.req | mem}}
+func NewGoTemplate(text string) (*Template, error) {
+	t, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid go-template: %w", err)
+	}
+	return &Template{execute: t.Execute}, nil
+}
+
+// NewJSONPath Parse a JSONPath template, ie: {.nodes[*].name}
+// Missing keys print nothing rather than failing, as with kubectl.
+func NewJSONPath(text string) (*Template, error) {
+	jp := jsonpath.New("output").AllowMissingKeys(true)
+	if err := jp.Parse(text); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath template: %w", err)
+	}
+	return &Template{execute: jp.Execute}, nil
+}
+
+// Print Render a report with the template to stdout
+// Any of the reports can be rendered: Report, FleetReport or Clusterdiff.
+func (t *Template) Print(report interface{}) error {
+	data, err := generic(report)
+	if err != nil {
+		return err
+	}
+	if err := t.execute(os.Stdout, data); err != nil {
+		return fmt.Errorf("could not render template: %w", err)
+	}
+	return nil
+}
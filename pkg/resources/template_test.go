@@ -0,0 +1,70 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Render a report with a template into a string
+func render(t *testing.T, tmpl *Template, report interface{}) string {
+	data, err := generic(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.execute(&b, data); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestTemplates(t *testing.T) {
+	c := loadFake(t,
+		testNode("node-1", "4", "8Gi", "110"),
+		testNode("node-2", "4", "8Gi", "110"),
+		testPod("web", "p1", "node-1", "1500m", "2Gi", true),
+	)
+	r := c.Report(true, false, true, false)
+
+	gt, err := NewGoTemplate(`{{range .nodes}}{{.name}} {{.cpu.req | cpu}} {{.memory.req | mem}} {{.cpu.util | pct}};{{end}}{{.cluster.pods.inuse}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := render(t, gt, r), "node-1 1.5 vCPU 2 GiB 37%;node-2 0 vCPU 0 MiB 0%;1"; got != want {
+		t.Errorf("go-template = %q, want %q", got, want)
+	}
+
+	jp, err := NewJSONPath(`{range .nodes[*]}{.name}={.memory.req} {end}{.missing}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := render(t, jp, r), "node-1=2147483648 node-2=0 "; got != want {
+		t.Errorf("jsonpath = %q, want %q", got, want)
+	}
+
+	if _, err := NewGoTemplate("{{.nodes"); err == nil {
+		t.Error("NewGoTemplate accepted an unclosed action")
+	}
+	if _, err := NewJSONPath("{.nodes[0]"); err == nil {
+		t.Error("NewJSONPath accepted an unclosed action")
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Alert levels, also the exit codes of a Nagios style check
+const (
+	AlertOK       = 0
+	AlertWarning  = 1
+	AlertCritical = 2
+	AlertUnknown  = 3
+)
+
+// Thresholds Utilization percentages by resource name at which an alert is raised
+// Keys are cpu, memory, pods or an extended resource, ie: nvidia.com/gpu.
+type Thresholds map[string]int64
+
+// Report whether a threshold can be set on a resource: cpu, memory, pods, a
+// standard extended resource (ie: ephemeral-storage, hugepages-2Mi) or one
+// qualified by a domain (ie: nvidia.com/gpu)
+func thresholdResource(name string) bool {
+	switch corev1.ResourceName(name) {
+	case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods, corev1.ResourceEphemeralStorage:
+		return true
+	}
+	if strings.HasPrefix(name, corev1.ResourceHugePagesPrefix) {
+		return len(name) > len(corev1.ResourceHugePagesPrefix)
+	}
+	return strings.Contains(name, "/") && len(validation.IsQualifiedName(name)) == 0
+}
+
+// ParseThresholds Parse thresholds of the form resource=percent, ie: cpu=80
+// Unknown resource names are rejected so a typo can not leave a check at OK.
+func ParseThresholds(specs []string) (Thresholds, error) {
+	t := Thresholds{}
+	for _, spec := range specs {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			return nil, fmt.Errorf("invalid threshold %q, use resource=percent", spec)
+		}
+		if !thresholdResource(kv[0]) {
+			return nil, fmt.Errorf("invalid threshold %q, unknown resource %q: use cpu, memory, pods or an extended resource", spec, kv[0])
+		}
+		pct, err := strconv.ParseInt(strings.TrimSuffix(kv[1], "%"), 10, 64)
+		if err != nil || pct < 0 {
+			return nil, fmt.Errorf("invalid threshold %q, use resource=percent", spec)
+		}
+		t[kv[0]] = pct
+	}
+	return t, nil
+}
+
+// Alert A utilization at or above a threshold
+type Alert struct {
+	Level     int    // AlertWarning or AlertCritical
+	Kind      string // node, namespace, group or cluster
+	Name      string
+	Resource  string
+	Util      int64
+	Threshold int64
+}
+
+// Return a short name for a resource in alert messages, ie: mem
+func alertResource(res string) string {
+	if res == string(corev1.ResourceMemory) {
+		return "mem"
+	}
+	return res
+}
+
+// Return the name of an alert level
+func levelName(level int) string {
+	switch level {
+	case AlertCritical:
+		return "CRITICAL"
+	case AlertWarning:
+		return "WARNING"
+	}
+	return "OK"
+}
+
+// Report whether thresholds were set, adding the alert columns to the tables
+func (c *Clustermetrics) alerting() bool {
+	return len(c.Warn) > 0 || len(c.Crit) > 0
+}
+
+// Return the alerts of a node, namespace or the cluster, the highest level for each resource
+func (c *Clustermetrics) check(kind string, name string, cpu Restat, mem Restat, pods Imetric, ext map[string]*Restat) []Alert {
+	var s []Alert
+	res := map[string]bool{}
+	for r := range c.Warn {
+		res[r] = true
+	}
+	for r := range c.Crit {
+		res[r] = true
+	}
+	var names []string
+	for r := range res {
+		names = append(names, r)
+	}
+	sort.Strings(names)
+	for _, r := range names {
+		var util int64
+		switch r {
+		case string(corev1.ResourcePods):
+			util = pods.Util
+		default:
+			// Resources the node does not have, ie: no GPUs, never alert
+			stat := resStat(r, cpu, mem, ext)
+			if stat.Avail == 0 && stat.Req == 0 {
+				continue
+			}
+			util = stat.Util
+		}
+		if t, ok := c.Crit[r]; ok && util >= t {
+			s = append(s, Alert{Level: AlertCritical, Kind: kind, Name: name, Resource: r, Util: util, Threshold: t})
+		} else if t, ok := c.Warn[r]; ok && util >= t {
+			s = append(s, Alert{Level: AlertWarning, Kind: kind, Name: name, Resource: r, Util: util, Threshold: t})
+		}
+	}
+	return s
+}
+
+// Alerts Return the alerts of the selected views against c.Warn and c.Crit, critical first
+// Every node and namespace is checked, whatever c.Order shows of them.
+func (c *Clustermetrics) Alerts(nodes bool, namespaces bool, cluster bool) []Alert {
+	var s []Alert
+	if nodes {
+		for _, name := range sortedKeys(c.Nodes) {
+			n := c.Nodes[name]
+			s = append(s, c.check("node", name, n.Cpu, n.Mem, n.Pods, n.Extended)...)
+		}
+	}
+	if namespaces {
+		for _, name := range sortedKeys(c.Namespaces) {
+			n := c.Namespaces[name]
+			s = append(s, c.check("namespace", name, n.Cpu, n.Mem, n.Pods, n.Extended)...)
+		}
+	}
+	if cluster {
+		s = append(s, c.check("cluster", "", c.Cpu, c.Mem, c.Pods, c.Extended)...)
+	}
+	sort.SliceStable(s, func(i, j int) bool { return s[i].Level > s[j].Level })
+	return s
+}
+
+// Return the keys of a map in alphabetical order
+func sortedKeys[V any](m map[string]V) []string {
+	var s []string
+	for k := range m {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// Return the alerts of each node
+func (c *Clustermetrics) nodeAlerts() map[string][]Alert {
+	m := make(map[string][]Alert)
+	for name, n := range c.Nodes {
+		m[name] = c.check("node", name, n.Cpu, n.Mem, n.Pods, n.Extended)
+	}
+	return m
+}
+
+// Return the alerts of each namespace
+func (c *Clustermetrics) nsAlerts() map[string][]Alert {
+	m := make(map[string][]Alert)
+	for name, n := range c.Namespaces {
+		m[name] = c.check("namespace", name, n.Cpu, n.Mem, n.Pods, n.Extended)
+	}
+	return m
+}
+
+// Return the alerts of the cluster by resource
+func (c *Clustermetrics) clusterAlerts() map[string][]Alert {
+	m := make(map[string][]Alert)
+	for _, a := range c.check("cluster", "", c.Cpu, c.Mem, c.Pods, c.Extended) {
+		m[a.Resource] = append(m[a.Resource], a)
+	}
+	return m
+}
+
+// AlertStatus Return the highest level of the alerts, AlertOK when there are none
+func AlertStatus(alerts []Alert) int {
+	status := AlertOK
+	for _, a := range alerts {
+		if a.Level > status {
+			status = a.Level
+		}
+	}
+	return status
+}
+
+// AlertSummary Return a one line summary of the alerts in the style of a Nagios check
+// ie: KUTIL CRITICAL - 1 critical, 1 warning: node worker-1 mem 92% >= 90%, cluster cpu 81% >= 80%
+func AlertSummary(alerts []Alert) string {
+	status := AlertStatus(alerts)
+	if status == AlertOK {
+		return "KUTIL OK - utilization below thresholds"
+	}
+	var crit, warn int
+	var msgs []string
+	for _, a := range alerts {
+		if a.Level == AlertCritical {
+			crit++
+		} else {
+			warn++
+		}
+		subject := a.Kind
+		if len(a.Name) > 0 {
+			subject += " " + a.Name
+		}
+		msgs = append(msgs, fmt.Sprintf("%s %s %d%% >= %d%%", subject, alertResource(a.Resource), a.Util, a.Threshold))
+	}
+	return fmt.Sprintf("KUTIL %s - %d critical, %d warning: %s", levelName(status), crit, warn, strings.Join(msgs, ", "))
+}
+
+// Return the ALERT cell of a table row, ie: CRIT mem, WARN cpu
+func alertText(alerts []Alert) string {
+	alerts = append([]Alert{}, alerts...)
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].Level > alerts[j].Level })
+	var s []string
+	for _, a := range alerts {
+		s = append(s, levelName(a.Level)[:4]+" "+alertResource(a.Resource))
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,82 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseThresholds(t *testing.T) {
+	got, err := ParseThresholds([]string{"cpu=80", "memory=85%", "pods=90", "nvidia.com/gpu=95", "hugepages-2Mi=50"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 || got["cpu"] != 80 || got["memory"] != 85 || got["pods"] != 90 {
+		t.Errorf("ParseThresholds = %v", got)
+	}
+	for _, spec := range []string{"cpu", "=80", "cpu=high", "cpu=-1", "cpuu=80", "gpu=80", "nvidia.com/=80", "hugepages-=80"} {
+		if _, err := ParseThresholds([]string{spec}); err == nil {
+			t.Errorf("ParseThresholds(%q) accepted an invalid threshold", spec)
+		}
+	}
+}
+
+func TestAlerts(t *testing.T) {
+	c := loadFake(t,
+		testNode("node-1", "4", "8Gi", "110"),
+		testNode("node-2", "4", "8Gi", "110"),
+		testPod("web", "p1", "node-1", "3600m", "4Gi", true),
+		testPod("web", "p2", "node-2", "1", "7Gi", true),
+	)
+	c.Warn = Thresholds{"cpu": 80, "memory": 50}
+	c.Crit = Thresholds{"memory": 85}
+
+	alerts := c.Alerts(true, false, true)
+	if AlertStatus(alerts) != AlertCritical {
+		t.Errorf("status = %d, want critical", AlertStatus(alerts))
+	}
+	// node-1 cpu 90% warn, node-1 mem 50% warn, node-2 mem 87% crit, cluster mem 68% warn
+	if len(alerts) != 4 || alerts[0].Name != "node-2" || alerts[0].Level != AlertCritical {
+		t.Fatalf("alerts = %+v", alerts)
+	}
+	want := "KUTIL CRITICAL - 1 critical, 3 warning: node node-2 mem 87% >= 85%, node node-1 cpu 90% >= 80%"
+	if got := AlertSummary(alerts); !strings.HasPrefix(got, want) {
+		t.Errorf("summary = %q, want prefix %q", got, want)
+	}
+	if got := c.nodeAlerts(); alertText(got["node-1"]) != "WARN cpu, WARN mem" || alertText(got["node-2"]) != "CRIT mem" {
+		t.Errorf("node alerts = %v", got)
+	}
+	if got := c.clusterAlerts(); len(got) != 1 || len(got["memory"]) != 1 || got["memory"][0].Level != AlertWarning {
+		t.Errorf("cluster alerts = %v", got)
+	}
+
+	// Nodes left out of the table by --top are still checked
+	c.Order = Order{By: "cpu", Top: 1}
+	if alerts := c.Alerts(true, false, false); AlertStatus(alerts) != AlertCritical || alerts[0].Name != "node-2" {
+		t.Errorf("alerts with --top 1 = %+v", alerts)
+	}
+	c.Order = Order{}
+
+	c.Warn, c.Crit = Thresholds{"nvidia.com/gpu": 1}, nil
+	if alerts := c.Alerts(true, true, true); AlertStatus(alerts) != AlertOK || AlertSummary(alerts) != "KUTIL OK - utilization below thresholds" {
+		t.Errorf("alerts on nodes without gpus = %+v", alerts)
+	}
+}
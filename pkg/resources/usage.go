@@ -0,0 +1,82 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// LoadUsage Retrieve actual cpu/memory consumption from the metrics.k8s.io API
+// Must be called after Load. An error usually means metrics-server is not
+// installed; the Clustermetrics object is left without usage data in that case.
+func (c *Clustermetrics) LoadUsage(mc metrics.Interface) error {
+	nodemetrics, err := mc.MetricsV1beta1().NodeMetricses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("metrics API not available: %w", err)
+	}
+	podmetrics, err := mc.MetricsV1beta1().PodMetricses(c.Filter.PodNamespace()).List(context.TODO(), metav1.ListOptions{LabelSelector: c.Filter.PodSelector})
+	if err != nil {
+		return fmt.Errorf("metrics API not available: %w", err)
+	}
+	c.LoadUsageItems(nodemetrics.Items, podmetrics.Items)
+	return nil
+}
+
+// LoadUsageItems Collect actual usage from node and pod metrics objects into the Clustermetrics object
+func (c *Clustermetrics) LoadUsageItems(nodemetrics []v1beta1.NodeMetrics, podmetrics []v1beta1.PodMetrics) {
+	// Node usage is reported by the kubelet for the whole node
+	for _, nm := range nodemetrics {
+		n, ok := c.Nodes[nm.Name]
+		if !ok {
+			continue
+		}
+		cpu := nm.Usage["cpu"]
+		mem := nm.Usage["memory"]
+		n.Cpu.Used = cpu.MilliValue()
+		n.Mem.Used = mem.Value()
+		c.Cpu.Used += cpu.MilliValue()
+		c.Mem.Used += mem.Value()
+	}
+
+	// Namespace usage is the sum of the usage of each container of the pods collected
+	// by Load, so the node, namespace and pod filters apply to usage as to requests
+	collected := make(map[string]bool, len(c.Podlist))
+	for _, p := range c.Podlist {
+		collected[p.Namespace+"/"+p.Name] = true
+	}
+	for _, pm := range podmetrics {
+		ns, ok := c.Namespaces[pm.Namespace]
+		if !ok || !collected[pm.Namespace+"/"+pm.Name] {
+			continue
+		}
+		for _, con := range pm.Containers {
+			cpu := con.Usage["cpu"]
+			mem := con.Usage["memory"]
+			ns.Cpu.Used += cpu.MilliValue()
+			ns.Mem.Used += mem.Value()
+		}
+	}
+	c.Usage = true
+}
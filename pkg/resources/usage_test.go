@@ -0,0 +1,90 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func TestLoadUsage(t *testing.T) {
+	c := loadFake(t,
+		testNode("node1", "4", "8Gi", "110"),
+		testNode("node2", "4", "8Gi", "110"),
+		testPod("default", "web", "node1", "1", "1Gi", true),
+	)
+	mc := fakeMetrics(
+		[]metricsv1beta1.NodeMetrics{
+			{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Usage: cpuMem("2", "4Gi")},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node2"}, Usage: cpuMem("500m", "1Gi")},
+			{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}, Usage: cpuMem("1", "1Gi")},
+		},
+		[]metricsv1beta1.PodMetrics{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+			Containers: []metricsv1beta1.ContainerMetrics{
+				{Name: "app", Usage: cpuMem("300m", "512Mi")},
+				{Name: "sidecar", Usage: cpuMem("50m", "64Mi")},
+			},
+		}, {
+			// Pods Load did not collect, ie: on a node left out by --node, do not count
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "elsewhere"},
+			Containers: []metricsv1beta1.ContainerMetrics{{Name: "app", Usage: cpuMem("1", "1Gi")}},
+		}},
+	)
+	if err := c.LoadUsage(mc); err != nil {
+		t.Fatalf("LoadUsage returned error: %v", err)
+	}
+	if !c.Usage {
+		t.Error("Usage should be set after loading metrics")
+	}
+	if got := c.Nodes["node1"].Cpu.Used; got != 2000 {
+		t.Errorf("node1 cpu used = %d, want 2000", got)
+	}
+	if got := c.Nodes["node2"].Mem.Used; got != 1<<30 {
+		t.Errorf("node2 mem used = %d, want 1Gi", got)
+	}
+	if c.Cpu.Used != 2500 || c.Mem.Used != 5<<30 {
+		t.Errorf("cluster used = %d cpu %d mem, want 2500 and 5Gi", c.Cpu.Used, c.Mem.Used)
+	}
+	ns := c.Namespaces["default"]
+	if ns.Cpu.Used != 350 || ns.Mem.Used != 576<<20 {
+		t.Errorf("default used = %d cpu %d mem, want 350 and 576Mi", ns.Cpu.Used, ns.Mem.Used)
+	}
+}
+
+func TestLoadUsageUnavailable(t *testing.T) {
+	c := loadFake(t, testNode("node1", "4", "8Gi", "110"), testPod("default", "web", "node1", "1", "1Gi", true))
+	mc := &metricsfake.Clientset{}
+	mc.AddReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("the server could not find the requested resource")
+	})
+	if err := c.LoadUsage(mc); err == nil {
+		t.Error("expected an error when the metrics API is missing")
+	}
+	if c.Usage {
+		t.Error("Usage should not be set when the metrics API is missing")
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Matches the ANSI color sequences of colored cells
+var colorSeq = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// View A table rendered for the dashboard, each row keyed by the object it shows
+type View struct {
+	Header []string
+	Rows   []ViewRow
+}
+
+// ViewRow A row of a View, several lines when a cell wraps, ie: taints
+type ViewRow struct {
+	Key   string
+	Lines []string
+}
+
+// Plain Return the lines of the row without colors
+func (r ViewRow) Plain() []string {
+	lines := make([]string, len(r.Lines))
+	for i, l := range r.Lines {
+		lines[i] = colorSeq.ReplaceAllString(l, "")
+	}
+	return lines
+}
+
+// Render a table into a View
+// Rows without a key, ie: the containers of a pod, belong to the row above.
+func newView(t *table, d Display) *View {
+	rendered := t.render(d)
+	v := &View{Header: rendered[0]}
+	for i, lines := range rendered[1:] {
+		key := t.keys[i+1]
+		if len(key) == 0 && len(v.Rows) > 0 {
+			last := &v.Rows[len(v.Rows)-1]
+			last.Lines = append(last.Lines, lines...)
+			continue
+		}
+		v.Rows = append(v.Rows, ViewRow{Key: key, Lines: lines})
+	}
+	return v
+}
+
+// ClusterView Return the cluster summary as a View
+func (c *Clustermetrics) ClusterView() *View {
+	return newView(c.clusterTable(), c.Display)
+}
+
+// NodeView Return the node summary as a View, rows keyed by node name
+func (c *Clustermetrics) NodeView() *View {
+	return newView(c.nodeTable(), c.Display)
+}
+
+// NamespaceView Return the namespace summary as a View, rows keyed by namespace name
+func (c *Clustermetrics) NamespaceView() *View {
+	return newView(c.namespaceTable(), c.Display)
+}
+
+// PodView Return the pods on a node and in a namespace as a View, rows keyed by namespace/name
+// An empty node or namespace matches every pod.
+func (c *Clustermetrics) PodView(node string, namespace string, containers bool) *View {
+	return newView(c.podTable(containers, func(p *Podmetrics) bool {
+		return (len(node) == 0 || p.Node == node) && (len(namespace) == 0 || p.Namespace == namespace)
+	}), c.Display)
+}
+
+// Filter Return the rows of the view containing s, ignoring case
+func (v *View) Filter(s string) *View {
+	if len(s) == 0 {
+		return v
+	}
+	s = strings.ToLower(s)
+	f := &View{Header: v.Header}
+	for _, row := range v.Rows {
+		if strings.Contains(strings.ToLower(row.Key), s) || strings.Contains(strings.ToLower(strings.Join(row.Plain(), "\n")), s) {
+			f.Rows = append(f.Rows, row)
+		}
+	}
+	return f
+}
@@ -0,0 +1,66 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestViews(t *testing.T) {
+	c := loadFake(t,
+		testNode("node1", "4", "8Gi", "110"),
+		testNode("node2", "4", "8Gi", "110"),
+		testPod("default", "web", "node1", "1", "1Gi", true),
+		testPod("data", "db", "node2", "2", "2Gi", true),
+		testPod("data", "cache", "node1", "500m", "512Mi", true),
+	)
+	c.Display = Display{Color: true}
+
+	v := c.NodeView()
+	if len(v.Rows) != 2 || v.Rows[0].Key != "node1" || v.Rows[1].Key != "node2" {
+		t.Fatalf("NodeView rows = %+v", v.Rows)
+	}
+	if !strings.HasPrefix(v.Header[0], "NODE") {
+		t.Errorf("NodeView header = %q", v.Header)
+	}
+	if plain := v.Rows[0].Plain()[0]; strings.Contains(plain, "\033") {
+		t.Errorf("Plain() kept colors: %q", plain)
+	}
+
+	// Pods on a node, largest first, containers belong to the row of their pod
+	v = c.PodView("node1", "", true)
+	if len(v.Rows) != 2 || v.Rows[0].Key != "default/web" || v.Rows[1].Key != "data/cache" {
+		t.Fatalf("PodView rows = %+v", v.Rows)
+	}
+	if len(v.Rows[0].Lines) != 2 || !strings.Contains(v.Rows[0].Lines[1], "app") {
+		t.Errorf("PodView container lines = %q", v.Rows[0].Lines)
+	}
+	if v = c.PodView("", "data", false); len(v.Rows) != 2 || len(v.Rows[0].Lines) != 1 {
+		t.Errorf("PodView(namespace) rows = %+v", v.Rows)
+	}
+
+	if f := c.NamespaceView().Filter("DEF"); len(f.Rows) != 1 || f.Rows[0].Key != "default" {
+		t.Errorf("Filter(DEF) rows = %+v", f.Rows)
+	}
+	if f := c.NodeView().Filter("nothing"); len(f.Rows) != 0 || len(f.Header) == 0 {
+		t.Errorf("Filter(nothing) = %+v", f)
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// UsageRefresh How often a Watcher refreshes actual usage from the metrics API
+// metrics-server scrapes the kubelets on a similar interval, polling faster
+// would only return the same numbers.
+const UsageRefresh = 15 * time.Second
+
+// Watcher Keeps node and pod data current using shared informers
+// Nodes and pods are listed once when the informers start, after that the
+// local cache follows the watch stream so each Load costs no API calls.
+type Watcher struct {
+	factories []informers.SharedInformerFactory
+	nodes     corelisters.NodeLister
+	pods      corelisters.PodLister
+	changed   chan struct{}
+	filter    Filter
+
+	// Optional metrics client and the most recent usage data
+	mc          metrics.Interface
+	mu          sync.Mutex
+	usageTime   time.Time
+	nodemetrics []v1beta1.NodeMetrics
+	podmetrics  []v1beta1.PodMetrics
+	usageErr    error
+}
+
+// NewWatcher constructor
+// mc may be nil to watch requests and limits only. The filter is pushed down
+// into the watches the same way Load pushes it into the list calls.
+func NewWatcher(cs kubernetes.Interface, mc metrics.Interface, f Filter) *Watcher {
+	w := &Watcher{mc: mc, filter: f, changed: make(chan struct{}, 1)}
+	// List options differ between nodes and pods, so each gets its own factory
+	nodeFactory := informers.NewSharedInformerFactoryWithOptions(cs, 0,
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			no := f.NodeListOptions()
+			o.LabelSelector = no.LabelSelector
+			o.FieldSelector = no.FieldSelector
+		}))
+	podFactory := informers.NewSharedInformerFactoryWithOptions(cs, 0,
+		informers.WithNamespace(f.PodNamespace()),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			po := f.PodListOptions()
+			o.LabelSelector = po.LabelSelector
+			o.FieldSelector = po.FieldSelector
+		}))
+	w.factories = []informers.SharedInformerFactory{nodeFactory, podFactory}
+	nodeInformer := nodeFactory.Core().V1().Nodes()
+	podInformer := podFactory.Core().V1().Pods()
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { w.notify() },
+		UpdateFunc: func(interface{}, interface{}) { w.notify() },
+		DeleteFunc: func(interface{}) { w.notify() },
+	}
+	nodeInformer.Informer().AddEventHandler(handler)
+	podInformer.Informer().AddEventHandler(handler)
+	w.nodes = nodeInformer.Lister()
+	w.pods = podInformer.Lister()
+	return w
+}
+
+// Start Run the informers until stop is closed and wait for the initial sync
+func (w *Watcher) Start(stop <-chan struct{}) error {
+	for _, f := range w.factories {
+		f.Start(stop)
+	}
+	for _, f := range w.factories {
+		for t, ok := range f.WaitForCacheSync(stop) {
+			if !ok {
+				return fmt.Errorf("could not sync %v cache", t)
+			}
+		}
+	}
+	return nil
+}
+
+// Changed Returns a channel which receives a value after a node or pod changes
+// Several changes between reads are collapsed into a single value.
+func (w *Watcher) Changed() <-chan struct{} {
+	return w.changed
+}
+
+// Signal a change without blocking the informer
+func (w *Watcher) notify() {
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Load Collect the cached node and pod data into the Clustermetrics object
+// The Clustermetrics Filter is replaced by the filter the Watcher was built with.
+// When a metrics client was given, actual usage is added from data at most
+// UsageRefresh old. The returned error is only about the informer cache; a
+// usage problem is reported by UsageErr instead.
+func (w *Watcher) Load(c *Clustermetrics) error {
+	c.Filter = w.filter
+	nodes, err := w.nodes.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	pods, err := w.pods.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	// The listers return pointers into the cache, LoadItems only reads the copies
+	nl := make([]corev1.Node, 0, len(nodes))
+	for _, n := range nodes {
+		nl = append(nl, *n)
+	}
+	pl := make([]corev1.Pod, 0, len(pods))
+	for _, p := range pods {
+		pl = append(pl, *p)
+	}
+	if err := c.LoadItems(nl, pl); err != nil {
+		return err
+	}
+
+	if w.mc != nil {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if time.Since(w.usageTime) >= UsageRefresh {
+			w.refreshUsage()
+		}
+		if w.usageErr == nil {
+			c.LoadUsageItems(w.nodemetrics, w.podmetrics)
+		}
+	}
+	return nil
+}
+
+// UsageErr Returns the error from the latest metrics API request, if any
+func (w *Watcher) UsageErr() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.usageErr
+}
+
+// Fetch fresh usage data from the metrics API, callers must hold w.mu
+func (w *Watcher) refreshUsage() {
+	w.usageTime = time.Now()
+	nm, err := w.mc.MetricsV1beta1().NodeMetricses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		w.usageErr = fmt.Errorf("metrics API not available: %w", err)
+		return
+	}
+	pm, err := w.mc.MetricsV1beta1().PodMetricses(w.filter.PodNamespace()).List(context.TODO(), metav1.ListOptions{LabelSelector: w.filter.PodSelector})
+	if err != nil {
+		w.usageErr = fmt.Errorf("metrics API not available: %w", err)
+		return
+	}
+	w.nodemetrics = nm.Items
+	w.podmetrics = pm.Items
+	w.usageErr = nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWatcher(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		testNode("node1", "4", "8Gi", "110"),
+		testPod("default", "web", "node1", "1", "1Gi", true),
+	)
+	stop := make(chan struct{})
+	defer close(stop)
+	w := NewWatcher(cs, nil, Filter{})
+	if err := w.Start(stop); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	c := NewCluster()
+	if err := w.Load(c); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if c.Nodes["node1"].Cpu.Req != 1000 {
+		t.Errorf("node1 cpu req = %d, want 1000", c.Nodes["node1"].Cpu.Req)
+	}
+
+	// Drain the notifications from the initial sync, then add a pod
+	select {
+	case <-w.Changed():
+	default:
+	}
+	pod := testPod("default", "api", "node1", "500m", "1Gi", true)
+	if _, err := cs.CoreV1().Pods("default").Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.Changed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change signalled after creating a pod")
+	}
+
+	// The cache may lag the notification slightly
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c = NewCluster()
+		if err := w.Load(c); err != nil {
+			t.Fatalf("Load returned error: %v", err)
+		}
+		if c.Nodes["node1"].Cpu.Req == 1500 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("node1 cpu req = %d, want 1500", c.Nodes["node1"].Cpu.Req)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright: 2020 Jed Record
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; Version 2 (GPLv2)
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+Full license text at: https://gnu.org/licenses/gpl-2.0.txt
+*/
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedrecord/kutil/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Return the value of the first label set on a node, the GA label before its deprecated beta
+func nodeLabel(node *corev1.Node, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := node.Labels[k]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// Collect the descriptive fields of a node shown in the wide view
+func nodeInfo(node *corev1.Node, n *Nodemetrics) {
+	n.Version = node.Status.NodeInfo.KubeletVersion
+	n.InstanceType = nodeLabel(node, corev1.LabelInstanceTypeStable, corev1.LabelInstanceType)
+	n.Zone = nodeLabel(node, corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone)
+	n.Created = node.CreationTimestamp.Time
+}
+
+// Return a dash for empty values so every column of the wide view holds something
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
+
+// PrintNodeWide Print the node summary with absolute values and descriptive fields
+// Each resource shows the amount requested, limited, allocatable and in capacity
+// rather than percentages, followed by the pods, the taints and the schedulability
+// of the node.
+func (c *Clustermetrics) PrintNodeWide() {
+	header := []string{"NODE", "STATUS", "LABEL", "AGE", "VERSION", "INSTANCE TYPE", "ZONE"}
+	alerts := c.nodeAlerts()
+	if c.alerting() {
+		header = append(header, "ALERT")
+	}
+	for _, res := range c.resourceNames() {
+		t := resTitle(res)
+		header = append(header, t+" REQ")
+		if limitCol(res) {
+			header = append(header, t+" LIM")
+		}
+		header = append(header, t+" ALLOC", t+" CAP")
+		if c.usageCol(res) {
+			header = append(header, t+" USED")
+		}
+	}
+	t := newTable(append(header, "PODS", "PODS ALLOC", "TAINTS", "SCHEDULABLE")...)
+	t.truncate("NODE", "LABEL", "INSTANCE TYPE", "ZONE", "TAINTS", "SCHEDULABLE")
+
+	now := time.Now()
+	for _, name := range c.SortedNodes() {
+		n := c.Nodes[name]
+		age := "-"
+		if !n.Created.IsZero() {
+			age = utils.FmtAge(now.Sub(n.Created))
+		}
+		row := []string{name, n.Status, orDash(c.NodeLabel(n)), age, orDash(n.Version), orDash(n.InstanceType), orDash(n.Zone)}
+		if c.alerting() {
+			row = append(row, orDash(alertText(alerts[name])))
+		}
+		for _, res := range c.resourceNames() {
+			r := resStat(res, n.Cpu, n.Mem, n.Extended)
+			row = append(row, fmtQuantity(res, r.Req))
+			if limitCol(res) {
+				row = append(row, fmtQuantity(res, r.Limit))
+			}
+			row = append(row, fmtQuantity(res, r.Avail), fmtQuantity(res, r.Cap))
+			if c.usageCol(res) {
+				row = append(row, fmtQuantity(res, r.Used))
+			}
+		}
+		taints := append([]string{}, n.Taints...)
+		sort.Strings(taints)
+		row = append(row, fmt.Sprint(n.Pods.Inuse), fmt.Sprint(n.Pods.Avail), strings.Join(taints, ","), schedText(n))
+		var cells []cell
+		for _, s := range row {
+			cells = append(cells, txt(s))
+		}
+		t.add(cells...)
+	}
+	t.print(c.Display)
+}
@@ -23,6 +23,7 @@ package utils
 import (
 	"fmt"
 	"os"
+	"time"
 )
 
 // LogError Error logging
@@ -87,6 +88,36 @@ func FmtPct(num int64) string {
 	return fmt.Sprintf("%d%%", num)
 }
 
+// FmtInt Convert an int64 to string
+func FmtInt(num int64) string {
+	return fmt.Sprintf("%d", num)
+}
+
+// FmtDelta Format a change with an explicit sign using one of the Fmt functions
+// Zero is shown as a plain "0" whatever the unit.
+func FmtDelta(num int64, f func(int64) string) string {
+	switch {
+	case num > 0:
+		return "+" + f(num)
+	case num < 0:
+		return "-" + f(-num)
+	}
+	return "0"
+}
+
+// FmtAge Convert a duration to a short age like kubectl shows, ie: 45s, 12m, 3h, 5d
+func FmtAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int64(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int64(d/time.Minute))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int64(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd", int64(d/(24*time.Hour)))
+}
+
 // MaxInt returns the larger of x or y.
 func MaxInt(x, y int) int {
 	if x < y {